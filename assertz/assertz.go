@@ -0,0 +1,213 @@
+// Package assertz provides minimal test assertions that work with any [testing.TB].
+//
+// Failures are reported at the first call site outside of this package and of the test
+// helpers marked with Helper, together with the source text of that line, so that a
+// failing assertion inside a table-driven loop or a test helper still points at the code
+// that made it:
+//
+//	assertion failed at user_test.go:42
+//		assertz.Equal(t, "alice", user.Name)
+//	expected: "alice"
+//	actual  : "bob"
+//
+// Every assertion returns whether it passed, and reports failures with tb.Errorf, so a
+// test continues after a failed assertion. Use the return value to stop early if needed.
+package assertz
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/goosz/commonz"
+)
+
+// packagePath is the import path of this package, whose frames are skipped when
+// locating the call site of a failed assertion.
+var packagePath = commonz.GetCaller(commonz.CurrentCaller).Package
+
+// helpers holds the names of the functions marked by Helper, whose frames are skipped
+// like those of this package.
+var helpers sync.Map
+
+// Helper marks the calling function as a test helper, whose failed assertions are
+// reported at the call to the helper rather than within it. The testing package does not
+// reveal the functions marked with tb.Helper, so helpers that wrap assertions should call
+// both:
+//
+//	func requireAdmin(t *testing.T, user User) {
+//		t.Helper()
+//		assertz.Helper()
+//		assertz.True(t, user.IsAdmin(), "user %s", user.Name)
+//	}
+func Helper() {
+	helpers.Store(commonz.GetCaller(commonz.ParentCaller).String(), struct{}{})
+}
+
+// Equal asserts that expected and actual are deeply equal, as determined by [reflect.DeepEqual].
+func Equal(tb testing.TB, expected, actual any, msgAndArgs ...any) bool {
+	tb.Helper()
+	if reflect.DeepEqual(expected, actual) {
+		return true
+	}
+	return fail(tb, fmt.Sprintf("expected: %s\nactual  : %s", format(expected), format(actual)), msgAndArgs)
+}
+
+// NotEqual asserts that expected and actual are not deeply equal.
+func NotEqual(tb testing.TB, expected, actual any, msgAndArgs ...any) bool {
+	tb.Helper()
+	if !reflect.DeepEqual(expected, actual) {
+		return true
+	}
+	return fail(tb, fmt.Sprintf("should not be: %s", format(actual)), msgAndArgs)
+}
+
+// True asserts that value is true.
+func True(tb testing.TB, value bool, msgAndArgs ...any) bool {
+	tb.Helper()
+	if value {
+		return true
+	}
+	return fail(tb, "should be true", msgAndArgs)
+}
+
+// False asserts that value is false.
+func False(tb testing.TB, value bool, msgAndArgs ...any) bool {
+	tb.Helper()
+	if !value {
+		return true
+	}
+	return fail(tb, "should be false", msgAndArgs)
+}
+
+// Nil asserts that value is nil, including typed nil pointers, slices, maps, channels,
+// functions and interfaces.
+func Nil(tb testing.TB, value any, msgAndArgs ...any) bool {
+	tb.Helper()
+	if isNil(value) {
+		return true
+	}
+	return fail(tb, fmt.Sprintf("expected nil, got: %s", format(value)), msgAndArgs)
+}
+
+// NotNil asserts that value is not nil, in the sense of Nil.
+func NotNil(tb testing.TB, value any, msgAndArgs ...any) bool {
+	tb.Helper()
+	if !isNil(value) {
+		return true
+	}
+	return fail(tb, "expected non-nil value", msgAndArgs)
+}
+
+// NoError asserts that err is nil.
+func NoError(tb testing.TB, err error, msgAndArgs ...any) bool {
+	tb.Helper()
+	if err == nil {
+		return true
+	}
+	return fail(tb, fmt.Sprintf("unexpected error: %v", err), msgAndArgs)
+}
+
+// Error asserts that err is not nil.
+func Error(tb testing.TB, err error, msgAndArgs ...any) bool {
+	tb.Helper()
+	if err != nil {
+		return true
+	}
+	return fail(tb, "expected an error, got nil", msgAndArgs)
+}
+
+// ErrorIs asserts that err matches target, as determined by [errors.Is].
+func ErrorIs(tb testing.TB, err, target error, msgAndArgs ...any) bool {
+	tb.Helper()
+	if errors.Is(err, target) {
+		return true
+	}
+	return fail(tb, fmt.Sprintf("error does not match target\nerror : %v\ntarget: %v", err, target), msgAndArgs)
+}
+
+// fail reports a failed assertion at the first call site outside of this package and of
+// the functions marked by Helper.
+func fail(tb testing.TB, failure string, msgAndArgs []any) bool {
+	tb.Helper()
+
+	var b strings.Builder
+	site, ok := callSite()
+	if !ok {
+		b.WriteString("assertion failed\n")
+	} else {
+		fmt.Fprintf(&b, "assertion failed at %s:%d\n", filepath.Base(site.File), site.Line)
+		if snippet, err := commonz.GetSourceSnippet(site, 0); err == nil {
+			fmt.Fprintf(&b, "\t%s\n", strings.TrimSpace(snippet.Lines[0].Text))
+		}
+	}
+	b.WriteString(failure)
+	if msg := formatMessage(msgAndArgs); msg != "" {
+		fmt.Fprintf(&b, "\nmessage : %s", msg)
+	}
+
+	tb.Errorf("%s", b.String())
+	return false
+}
+
+// callSite returns the innermost frame of the current stack outside of this package and
+// of the functions marked by Helper. Returns false if there is none.
+func callSite() (commonz.CallerFrame, bool) {
+	for _, frame := range commonz.GetStack(commonz.ParentCaller) {
+		if frame.Package == packagePath {
+			continue
+		}
+		if _, ok := helpers.Load(frame.CallerInfo.String()); ok {
+			continue
+		}
+		return frame, !frame.IsUnknown()
+	}
+	return commonz.CallerFrame{}, false
+}
+
+// formatMessage formats the optional message arguments of an assertion: either a single
+// value, a format string followed by its arguments, or values separated by spaces.
+func formatMessage(msgAndArgs []any) string {
+	switch len(msgAndArgs) {
+	case 0:
+		return ""
+	case 1:
+		return fmt.Sprint(msgAndArgs[0])
+	default:
+		if format, ok := msgAndArgs[0].(string); ok {
+			return fmt.Sprintf(format, msgAndArgs[1:]...)
+		}
+		return strings.TrimSuffix(fmt.Sprintln(msgAndArgs...), "\n")
+	}
+}
+
+// format renders a value for a failure message, quoting strings and showing the type
+// of values whose printed form would otherwise be ambiguous.
+func format(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return "<nil>"
+	case string:
+		return fmt.Sprintf("%q", v)
+	default:
+		return fmt.Sprintf("%#v (%s)", v, commonz.TypeName(reflect.TypeOf(v)))
+	}
+}
+
+// isNil reports whether value is nil or holds a nil pointer, slice, map, channel, function or interface.
+func isNil(value any) bool {
+	if value == nil {
+		return true
+	}
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Chan, reflect.Func, reflect.Interface, reflect.Map, reflect.Pointer, reflect.Slice, reflect.UnsafePointer:
+		return v.IsNil()
+	default:
+		return false
+	}
+}
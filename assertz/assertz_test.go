@@ -0,0 +1,154 @@
+package assertz_test
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/goosz/commonz/assertz"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingTB is a testing.TB that records failures instead of failing the test.
+type recordingTB struct {
+	testing.TB
+	failures []string
+}
+
+func (r *recordingTB) Helper() {}
+
+func (r *recordingTB) Errorf(format string, args ...any) {
+	r.failures = append(r.failures, fmt.Sprintf(format, args...))
+}
+
+// currentLine returns the line number of its caller.
+func currentLine() int {
+	_, _, line, _ := runtime.Caller(1)
+	return line
+}
+
+func TestEqual(t *testing.T) {
+	tb := &recordingTB{}
+	require.True(t, assertz.Equal(tb, []int{1, 2}, []int{1, 2}))
+	require.Empty(t, tb.failures)
+
+	line := currentLine() + 1
+	require.False(t, assertz.Equal(tb, "alice", "bob"))
+	require.Equal(t, []string{fmt.Sprintf(
+		"assertion failed at assertz_test.go:%d\n"+
+			"\trequire.False(t, assertz.Equal(tb, \"alice\", \"bob\"))\n"+
+			"expected: \"alice\"\n"+
+			"actual  : \"bob\"", line)}, tb.failures)
+}
+
+func TestEqual_typesInMessage(t *testing.T) {
+	tb := &recordingTB{}
+	assertz.Equal(tb, 1, int64(1))
+	require.Len(t, tb.failures, 1)
+	require.Contains(t, tb.failures[0], "expected: 1 (int)\nactual  : 1 (int64)")
+}
+
+func TestFailureReportedOutsideHelpers(t *testing.T) {
+	tb := &recordingTB{}
+	line := currentLine() + 1
+	for _, v := range []int{1, 2} {
+		assertz.True(tb, v == 1, "value %d", v)
+	}
+	require.Equal(t, []string{fmt.Sprintf(
+		"assertion failed at assertz_test.go:%d\n"+
+			"\tassertz.True(tb, v == 1, \"value %%d\", v)\n"+
+			"should be true\n"+
+			"message : value 2", line+1)}, tb.failures)
+}
+
+// requireName is a user-defined test helper wrapping an assertion.
+func requireName(tb testing.TB, expected, actual string) {
+	tb.Helper()
+	assertz.Helper()
+	assertz.Equal(tb, expected, actual, "name")
+}
+
+// requireUser is a helper calling another helper.
+func requireUser(tb testing.TB, name string) {
+	tb.Helper()
+	assertz.Helper()
+	requireName(tb, "alice", name)
+}
+
+func TestFailureReportedOutsideUserHelpers(t *testing.T) {
+	tb := &recordingTB{}
+	line := currentLine() + 1
+	requireName(tb, "alice", "bob")
+	requireUser(tb, "carol")
+
+	require.Equal(t, []string{
+		fmt.Sprintf("assertion failed at assertz_test.go:%d\n"+
+			"\trequireName(tb, \"alice\", \"bob\")\n"+
+			"expected: \"alice\"\n"+
+			"actual  : \"bob\"\n"+
+			"message : name", line),
+		fmt.Sprintf("assertion failed at assertz_test.go:%d\n"+
+			"\trequireUser(tb, \"carol\")\n"+
+			"expected: \"alice\"\n"+
+			"actual  : \"carol\"\n"+
+			"message : name", line+1),
+	}, tb.failures)
+}
+
+func TestAssertions(t *testing.T) {
+	var nilPtr *int
+	errTarget := errors.New("target")
+
+	tests := []struct {
+		name    string
+		assert  func(testing.TB) bool
+		pass    bool
+		failure string
+	}{
+		{"NotEqual pass", func(tb testing.TB) bool { return assertz.NotEqual(tb, 1, 2) }, true, ""},
+		{"NotEqual fail", func(tb testing.TB) bool { return assertz.NotEqual(tb, 1, 1) }, false, "should not be: 1 (int)"},
+		{"False pass", func(tb testing.TB) bool { return assertz.False(tb, false) }, true, ""},
+		{"False fail", func(tb testing.TB) bool { return assertz.False(tb, true) }, false, "should be false"},
+		{"Nil untyped", func(tb testing.TB) bool { return assertz.Nil(tb, nil) }, true, ""},
+		{"Nil typed pointer", func(tb testing.TB) bool { return assertz.Nil(tb, nilPtr) }, true, ""},
+		{"Nil fail", func(tb testing.TB) bool { return assertz.Nil(tb, 0) }, false, "expected nil, got: 0 (int)"},
+		{"NotNil pass", func(tb testing.TB) bool { return assertz.NotNil(tb, []int{}) }, true, ""},
+		{"NotNil fail", func(tb testing.TB) bool { return assertz.NotNil(tb, nilPtr) }, false, "expected non-nil value"},
+		{"NoError pass", func(tb testing.TB) bool { return assertz.NoError(tb, nil) }, true, ""},
+		{"NoError fail", func(tb testing.TB) bool { return assertz.NoError(tb, io.EOF) }, false, "unexpected error: EOF"},
+		{"Error pass", func(tb testing.TB) bool { return assertz.Error(tb, io.EOF) }, true, ""},
+		{"Error fail", func(tb testing.TB) bool { return assertz.Error(tb, nil) }, false, "expected an error, got nil"},
+		{"ErrorIs pass", func(tb testing.TB) bool {
+			return assertz.ErrorIs(tb, fmt.Errorf("wrapped: %w", errTarget), errTarget)
+		}, true, ""},
+		{"ErrorIs fail", func(tb testing.TB) bool { return assertz.ErrorIs(tb, io.EOF, errTarget) }, false,
+			"error does not match target\nerror : EOF\ntarget: target"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tb := &recordingTB{}
+			require.Equal(t, tt.pass, tt.assert(tb))
+			if tt.pass {
+				require.Empty(t, tb.failures)
+			} else {
+				require.Len(t, tb.failures, 1)
+				require.Contains(t, tb.failures[0], tt.failure)
+			}
+		})
+	}
+}
+
+func TestMessageFormatting(t *testing.T) {
+	tb := &recordingTB{}
+	assertz.True(tb, false, "single")
+	assertz.True(tb, false, 42, "not a format")
+	assertz.True(tb, false, "user %s has %d roles", "alice", 2)
+	require.Len(t, tb.failures, 3)
+	require.Contains(t, tb.failures[0], "message : single")
+	require.True(t, strings.HasSuffix(tb.failures[1], "message : 42 not a format"), tb.failures[1])
+	require.Contains(t, tb.failures[2], "message : user alice has 2 roles")
+}
@@ -0,0 +1,41 @@
+package commonz
+
+import (
+	"context"
+	"slices"
+)
+
+// MaxCallerBreadcrumbs is the maximum number of breadcrumbs kept by WithCallerBreadcrumb.
+// Once reached, the oldest breadcrumbs are dropped as new ones are pushed.
+const MaxCallerBreadcrumbs = 32
+
+// breadcrumbsContextKey is the context key under which the breadcrumbs are stored.
+type breadcrumbsContextKey struct{}
+
+// WithCallerBreadcrumb returns a copy of ctx with the CallerInfo of the function calling
+// WithCallerBreadcrumb appended to its breadcrumbs. Calling it at each logical entry point
+// records the path a request took through them:
+//
+//	func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+//		ctx := commonz.WithCallerBreadcrumb(r.Context())
+//		h.service.Do(ctx)
+//	}
+//
+// At most MaxCallerBreadcrumbs are kept, so that long-lived contexts do not grow without bound.
+func WithCallerBreadcrumb(ctx context.Context) context.Context {
+	// The slice is copied, since contexts derived from the same parent must not share appended elements
+	crumbs, _ := ctx.Value(breadcrumbsContextKey{}).([]CallerInfo)
+	if len(crumbs) >= MaxCallerBreadcrumbs {
+		crumbs = crumbs[len(crumbs)-MaxCallerBreadcrumbs+1:]
+	}
+	next := make([]CallerInfo, len(crumbs), len(crumbs)+1)
+	copy(next, crumbs)
+	return context.WithValue(ctx, breadcrumbsContextKey{}, append(next, GetCaller(ParentCaller)))
+}
+
+// CallerBreadcrumbs returns the breadcrumbs recorded in ctx by WithCallerBreadcrumb,
+// oldest first. Returns nil if there are none.
+func CallerBreadcrumbs(ctx context.Context) []CallerInfo {
+	crumbs, _ := ctx.Value(breadcrumbsContextKey{}).([]CallerInfo)
+	return slices.Clone(crumbs)
+}
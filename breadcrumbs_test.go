@@ -0,0 +1,67 @@
+package commonz_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/goosz/commonz"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCallerBreadcrumbs(t *testing.T) {
+	crumbs := breadcrumbHandler(context.Background())
+
+	require.Equal(t, []commonz.CallerInfo{
+		{Package: "github.com/goosz/commonz_test", Function: "breadcrumbHandler"},
+		{Package: "github.com/goosz/commonz_test", Function: "breadcrumbService"},
+		{Package: "github.com/goosz/commonz_test", Function: "breadcrumbRepository"},
+	}, crumbs)
+}
+
+func TestCallerBreadcrumbs_empty(t *testing.T) {
+	require.Nil(t, commonz.CallerBreadcrumbs(context.Background()))
+}
+
+func TestCallerBreadcrumbs_bounded(t *testing.T) {
+	ctx := context.Background()
+	for range commonz.MaxCallerBreadcrumbs + 5 {
+		ctx = commonz.WithCallerBreadcrumb(ctx)
+	}
+	ctx = breadcrumbLast(ctx)
+
+	crumbs := commonz.CallerBreadcrumbs(ctx)
+	require.Len(t, crumbs, commonz.MaxCallerBreadcrumbs)
+	require.Equal(t, "TestCallerBreadcrumbs_bounded", crumbs[0].Function)
+	require.Equal(t, "breadcrumbLast", crumbs[len(crumbs)-1].Function, "the newest breadcrumb should be kept")
+}
+
+func TestCallerBreadcrumbs_siblingsIndependent(t *testing.T) {
+	parent := commonz.WithCallerBreadcrumb(context.Background())
+	first := breadcrumbLast(parent)
+	second := commonz.WithCallerBreadcrumb(parent)
+
+	require.Len(t, commonz.CallerBreadcrumbs(parent), 1)
+	require.Equal(t, "breadcrumbLast", commonz.CallerBreadcrumbs(first)[1].Function)
+	require.Equal(t, "TestCallerBreadcrumbs_siblingsIndependent", commonz.CallerBreadcrumbs(second)[1].Function)
+
+	crumbs := commonz.CallerBreadcrumbs(parent)
+	crumbs[0].Function = "modified"
+	require.Equal(t, "TestCallerBreadcrumbs_siblingsIndependent", commonz.CallerBreadcrumbs(parent)[0].Function,
+		"returned breadcrumbs should not alias the context")
+}
+
+func breadcrumbHandler(ctx context.Context) []commonz.CallerInfo {
+	return breadcrumbService(commonz.WithCallerBreadcrumb(ctx))
+}
+
+func breadcrumbService(ctx context.Context) []commonz.CallerInfo {
+	return breadcrumbRepository(commonz.WithCallerBreadcrumb(ctx))
+}
+
+func breadcrumbRepository(ctx context.Context) []commonz.CallerInfo {
+	return commonz.CallerBreadcrumbs(commonz.WithCallerBreadcrumb(ctx))
+}
+
+func breadcrumbLast(ctx context.Context) context.Context {
+	return commonz.WithCallerBreadcrumb(ctx)
+}
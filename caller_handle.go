@@ -0,0 +1,65 @@
+package commonz
+
+import "runtime"
+
+// CallerHandle is a compact, comparable identifier of a call site, obtained with
+// GetCallerHandle. It wraps the program counter of the call, so it can be obtained
+// without allocating and used as a map key, and only resolved to a CallerInfo when needed.
+//
+// Unlike CallerInfo, which identifies a function, a CallerHandle identifies a position
+// within a function: handles obtained from different calls in the same function differ.
+// Likewise, when the calling function is inlined, each place it is inlined into yields
+// its own handle. The zero CallerHandle represents an unknown caller.
+type CallerHandle struct {
+	pc uintptr
+}
+
+// callerHandleSymbolizer caches the resolution of CallerHandles.
+var callerHandleSymbolizer Symbolizer
+
+// GetCallerHandle returns a CallerHandle for the caller at the specified depth in the
+// call stack. The depth parameter has the same meaning as for GetCaller.
+//
+// It does not allocate, which makes it suitable for hot paths that only need to
+// distinguish call sites, such as per-site counters. Returns the zero CallerHandle if the
+// caller cannot be determined at the specified depth.
+func GetCallerHandle(depth int) CallerHandle {
+	if depth < 0 {
+		return CallerHandle{}
+	}
+	var pcs [1]uintptr
+	if runtime.Callers(depth+2, pcs[:]) == 0 { // +2 to skip runtime.Callers and GetCallerHandle itself
+		return CallerHandle{}
+	}
+	return CallerHandle{pc: pcs[0]}
+}
+
+// PC returns the program counter wrapped by the handle, in the form returned by
+// [runtime.Callers]; see SymbolizePCs.
+func (h CallerHandle) PC() uintptr {
+	return h.pc
+}
+
+// IsUnknown returns true if the handle represents an unknown caller.
+func (h CallerHandle) IsUnknown() bool {
+	return h.pc == 0
+}
+
+// Frame resolves the handle to a CallerFrame. Resolutions are cached, so repeated calls
+// for the same call site are cheap and do not allocate.
+func (h CallerHandle) Frame() CallerFrame {
+	if h.pc == 0 {
+		return CallerFrame{CallerInfo: unknownCallerInfo()}
+	}
+	return callerHandleSymbolizer.lookup(h.pc)
+}
+
+// CallerInfo resolves the handle to the CallerInfo of the calling function, as GetCaller
+// would have returned it. Resolutions are cached as for Frame.
+func (h CallerHandle) CallerInfo() CallerInfo {
+	return h.Frame().CallerInfo
+}
+
+func (h CallerHandle) String() string {
+	return h.CallerInfo().String()
+}
@@ -0,0 +1,59 @@
+package commonz_test
+
+import (
+	"testing"
+
+	"github.com/goosz/commonz"
+)
+
+// BenchmarkGetCallerHandle benchmarks GetCallerHandle against GetCaller
+func BenchmarkGetCallerHandle(b *testing.B) {
+	b.Run("GetCallerHandle", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = commonz.GetCallerHandle(commonz.CurrentCaller)
+		}
+	})
+
+	b.Run("GetCaller", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = commonz.GetCaller(commonz.CurrentCaller)
+		}
+	})
+}
+
+// BenchmarkCallerHandle_CallerInfo benchmarks resolving a cached handle
+func BenchmarkCallerHandle_CallerInfo(b *testing.B) {
+	handle := commonz.GetCallerHandle(commonz.CurrentCaller)
+	_ = handle.CallerInfo()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = handle.CallerInfo()
+	}
+}
+
+// BenchmarkCallerHandle_mapKey benchmarks counting calls per site using handles as map keys
+func BenchmarkCallerHandle_mapKey(b *testing.B) {
+	counts := make(map[commonz.CallerHandle]int)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		counts[commonz.GetCallerHandle(commonz.CurrentCaller)]++
+	}
+}
+
+// BenchmarkConcurrentCallerHandle_CallerInfo benchmarks resolving cached handles under concurrent access
+func BenchmarkConcurrentCallerHandle_CallerInfo(b *testing.B) {
+	handle := commonz.GetCallerHandle(commonz.CurrentCaller)
+	_ = handle.CallerInfo()
+
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			_ = handle.CallerInfo()
+		}
+	})
+}
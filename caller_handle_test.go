@@ -0,0 +1,80 @@
+package commonz_test
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/goosz/commonz"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetCallerHandle(t *testing.T) {
+	_, file, line, _ := runtime.Caller(0)
+	handle := commonz.GetCallerHandle(commonz.CurrentCaller)
+
+	require.False(t, handle.IsUnknown())
+	require.Equal(t, commonz.GetCaller(commonz.CurrentCaller), handle.CallerInfo())
+	require.Equal(t, "github.com/goosz/commonz_test.TestGetCallerHandle", handle.String())
+
+	frame := handle.Frame()
+	require.Equal(t, file, frame.File)
+	require.Equal(t, line+1, frame.Line)
+	require.Equal(t, handle.PC(), frame.PC)
+	require.Equal(t, commonz.SymbolizePCs([]uintptr{handle.PC()}), []commonz.CallerFrame{frame})
+}
+
+func TestGetCallerHandle_depth(t *testing.T) {
+	handle := func() commonz.CallerHandle {
+		return commonz.GetCallerHandle(commonz.ParentCaller)
+	}()
+	require.Equal(t, "TestGetCallerHandle_depth", handle.CallerInfo().Function)
+}
+
+func TestGetCallerHandle_mapKey(t *testing.T) {
+	counts := make(map[commonz.CallerHandle]int)
+	for range 4 {
+		counts[callerHandleHelper()]++
+	}
+
+	require.Len(t, counts, 1, "handles from the same call site should be equal")
+	for handle, n := range counts {
+		require.Equal(t, 4, n)
+		require.Equal(t, "callerHandleHelper", handle.CallerInfo().Function)
+	}
+
+	first := commonz.GetCallerHandle(commonz.CurrentCaller)
+	second := commonz.GetCallerHandle(commonz.CurrentCaller)
+	require.NotEqual(t, first, second, "handles from different call sites should differ")
+	require.Equal(t, first.CallerInfo(), second.CallerInfo())
+}
+
+func TestGetCallerHandle_unknown(t *testing.T) {
+	for _, handle := range []commonz.CallerHandle{
+		{},
+		commonz.GetCallerHandle(-1),
+		commonz.GetCallerHandle(100),
+	} {
+		require.True(t, handle.IsUnknown())
+		require.True(t, handle.CallerInfo().IsUnknown())
+		require.True(t, handle.Frame().IsUnknown())
+	}
+}
+
+func TestGetCallerHandle_allocations(t *testing.T) {
+	handle := callerHandleHelper()
+	_ = handle.CallerInfo() // Populate the cache
+
+	allocs := testing.AllocsPerRun(100, func() {
+		_ = callerHandleHelper()
+	})
+	require.Zero(t, allocs, "GetCallerHandle should not allocate")
+
+	allocs = testing.AllocsPerRun(100, func() {
+		_ = handle.CallerInfo()
+	})
+	require.Zero(t, allocs, "resolving a cached handle should not allocate")
+}
+
+func callerHandleHelper() commonz.CallerHandle {
+	return commonz.GetCallerHandle(commonz.CurrentCaller)
+}
@@ -0,0 +1,135 @@
+package commonz
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// DeprecationPolicy determines what happens when a function calling Deprecated is used.
+type DeprecationPolicy int32
+
+// Deprecation policies for SetDeprecationPolicy
+const (
+	WarnOnDeprecation  DeprecationPolicy = iota // Write a warning once per call site (the default)
+	ErrorOnDeprecation                          // Return a *DeprecationError from Deprecated
+	PanicOnDeprecation                          // Panic with a *DeprecationError
+)
+
+// DeprecationError describes a call to a deprecated function.
+type DeprecationError struct {
+	Function CallerInfo // The deprecated function, i.e. the function that called Deprecated
+	Caller   CallerInfo // The first caller of Function outside of Function's package
+	File     string     // The source file of the call made by Caller
+	Line     int        // The source line of the call made by Caller
+	Message  string     // The message given to Deprecated, e.g. suggesting a replacement
+}
+
+func (e *DeprecationError) Error() string {
+	msg := fmt.Sprintf("deprecated: %s called from %s (%s:%d)", e.Function, e.Caller, e.File, e.Line)
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+	return msg
+}
+
+// deprecationStackDepth is the number of frames searched for the external caller of a
+// deprecated function before resorting to the entire stack.
+const deprecationStackDepth = 16
+
+var (
+	deprecationPolicy     atomic.Int32
+	deprecationSymbolizer Symbolizer // Caches the resolution of deprecated functions and their callers
+	deprecationWarned     sync.Map   // Call sites already warned about, by program counter
+
+	deprecationMu     sync.Mutex
+	deprecationOutput io.Writer = os.Stderr // Guarded by deprecationMu
+)
+
+// SetDeprecationPolicy sets the process-wide policy applied by Deprecated.
+func SetDeprecationPolicy(policy DeprecationPolicy) {
+	deprecationPolicy.Store(int32(policy))
+}
+
+// SetDeprecationOutput sets the writer that deprecation warnings are written to.
+// The default is [os.Stderr].
+func SetDeprecationOutput(w io.Writer) {
+	deprecationMu.Lock()
+	defer deprecationMu.Unlock()
+	deprecationOutput = w
+}
+
+// Deprecated marks the function calling it as deprecated. It is intended to be called
+// at the start of the deprecated function:
+//
+//	func OldThing() error {
+//		if err := commonz.Deprecated("use NewThing instead"); err != nil {
+//			return err
+//		}
+//		...
+//	}
+//
+// The external caller is the first function on the stack outside of the deprecated
+// function's package (see GetCallerOutside), so internal calls within the package are
+// attributed to the code that entered it. Depending on the policy set with SetDeprecationPolicy:
+//   - WarnOnDeprecation: a warning is written once per external call site, and nil is returned.
+//     Call sites that cannot be resolved are warned about on every call.
+//   - ErrorOnDeprecation: a *DeprecationError is returned on every call.
+//   - PanicOnDeprecation: Deprecated panics with a *DeprecationError.
+func Deprecated(message string) error {
+	function, site := deprecationSite()
+	policy := DeprecationPolicy(deprecationPolicy.Load())
+	if policy == WarnOnDeprecation && site.PC != 0 {
+		if _, warned := deprecationWarned.Load(site.PC); warned {
+			return nil
+		}
+	}
+
+	err := &DeprecationError{
+		Function: function,
+		Caller:   site.CallerInfo,
+		File:     site.File,
+		Line:     site.Line,
+		Message:  message,
+	}
+	switch policy {
+	case ErrorOnDeprecation:
+		return err
+	case PanicOnDeprecation:
+		panic(err)
+	default:
+		if site.PC != 0 {
+			if _, warned := deprecationWarned.LoadOrStore(site.PC, struct{}{}); warned {
+				return nil
+			}
+		}
+		deprecationMu.Lock()
+		defer deprecationMu.Unlock()
+		_, _ = fmt.Fprintf(deprecationOutput, "WARNING: %s\n", err)
+		return nil
+	}
+}
+
+// deprecationSite returns the function that called Deprecated, and the frame of its first
+// caller outside of its package. The frame's PC is 0 if there is no such caller.
+func deprecationSite() (CallerInfo, CallerFrame) {
+	var buf [deprecationStackDepth]uintptr
+	pcs := buf[:runtime.Callers(3, buf[:])] // Skip runtime.Callers, deprecationSite and Deprecated
+	if len(pcs) == len(buf) {
+		pcs = callers(2) // Skip deprecationSite and Deprecated
+	}
+	if len(pcs) == 0 {
+		return unknownCallerInfo(), CallerFrame{CallerInfo: unknownCallerInfo()}
+	}
+
+	function := deprecationSymbolizer.lookup(pcs[0]).CallerInfo
+	for _, pc := range pcs[1:] {
+		if frame := deprecationSymbolizer.lookup(pc); frame.Package != function.Package {
+			return function, frame
+		}
+	}
+	return function, CallerFrame{CallerInfo: unknownCallerInfo()}
+}
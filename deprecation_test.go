@@ -0,0 +1,101 @@
+package commonz_test
+
+import (
+	"bytes"
+	"os"
+	"regexp"
+	"testing"
+
+	"github.com/goosz/commonz"
+	"github.com/stretchr/testify/require"
+)
+
+// useDeprecationOutput redirects deprecation warnings to a buffer for the duration of the test.
+func useDeprecationOutput(t *testing.T, policy commonz.DeprecationPolicy) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	commonz.ResetDeprecationWarnings()
+	commonz.SetDeprecationOutput(&buf)
+	commonz.SetDeprecationPolicy(policy)
+	t.Cleanup(func() {
+		commonz.SetDeprecationOutput(os.Stderr)
+		commonz.SetDeprecationPolicy(commonz.WarnOnDeprecation)
+		commonz.ResetDeprecationWarnings()
+	})
+	return &buf
+}
+
+func TestDeprecated_warnOncePerSite(t *testing.T) {
+	buf := useDeprecationOutput(t, commonz.WarnOnDeprecation)
+
+	for range 3 {
+		require.NoError(t, commonz.DeprecatedForTest())
+	}
+	require.NoError(t, commonz.DeprecatedForTest()) // A different call site
+
+	lines := regexp.MustCompile(`(?m)^WARNING: .*$`).FindAllString(buf.String(), -1)
+	require.Len(t, lines, 2, "should warn once per call site")
+	require.Regexp(t,
+		`^WARNING: deprecated: github.com/goosz/commonz.deprecatedForTestInternal called from `+
+			`github.com/goosz/commonz_test.TestDeprecated_warnOncePerSite \(.*deprecation_test.go:\d+\): use something else$`,
+		lines[0])
+	require.NotEqual(t, lines[0], lines[1])
+}
+
+func TestDeprecated_warnedSiteIsCheap(t *testing.T) {
+	buf := useDeprecationOutput(t, commonz.WarnOnDeprecation)
+
+	// AllocsPerRun makes a warm-up call, which warns, before measuring.
+	allocs := testing.AllocsPerRun(100, func() {
+		_ = commonz.DeprecatedForTest()
+	})
+	require.Zero(t, allocs, "calls from a site already warned about should not allocate")
+	require.Len(t, regexp.MustCompile(`(?m)^WARNING: `).FindAllString(buf.String(), -1), 1)
+}
+
+func TestDeprecated_error(t *testing.T) {
+	buf := useDeprecationOutput(t, commonz.ErrorOnDeprecation)
+
+	for range 2 {
+		err := commonz.DeprecatedForTest()
+		var deprecationErr *commonz.DeprecationError
+		require.ErrorAs(t, err, &deprecationErr, "should return an error on every call")
+		require.Equal(t, commonz.CallerInfo{
+			Package:  "github.com/goosz/commonz",
+			Function: "deprecatedForTestInternal",
+		}, deprecationErr.Function)
+		require.Equal(t, commonz.CallerInfo{
+			Package:  "github.com/goosz/commonz_test",
+			Function: "TestDeprecated_error",
+		}, deprecationErr.Caller)
+		require.Regexp(t, `deprecation_test.go$`, deprecationErr.File)
+		require.Positive(t, deprecationErr.Line)
+		require.Equal(t, "use something else", deprecationErr.Message)
+	}
+	require.Empty(t, buf.String(), "should not warn when returning errors")
+}
+
+func TestDeprecated_panic(t *testing.T) {
+	useDeprecationOutput(t, commonz.PanicOnDeprecation)
+
+	defer func() {
+		deprecationErr, ok := recover().(*commonz.DeprecationError)
+		require.True(t, ok, "should panic with a *DeprecationError")
+		require.Equal(t, "TestDeprecated_panic", deprecationErr.Caller.Function)
+	}()
+	_ = commonz.DeprecatedForTest()
+	t.Fatal("should have panicked")
+}
+
+func TestDeprecationError_Error(t *testing.T) {
+	err := &commonz.DeprecationError{
+		Function: commonz.CallerInfo{Package: "example.com/lib", Function: "Old"},
+		Caller:   commonz.CallerInfo{Package: "example.com/app", Function: "main"},
+		File:     "/src/app/main.go",
+		Line:     12,
+	}
+	require.Equal(t, "deprecated: example.com/lib.Old called from example.com/app.main (/src/app/main.go:12)", err.Error())
+
+	err.Message = "use New"
+	require.Equal(t, "deprecated: example.com/lib.Old called from example.com/app.main (/src/app/main.go:12): use New", err.Error())
+}
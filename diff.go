@@ -0,0 +1,469 @@
+package commonz
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// maxDiffAlignment bounds the product of the lengths of two slices aligned by their
+// longest common subsequence; longer slices are compared element by element.
+const maxDiffAlignment = 1 << 20
+
+// DiffKind is the kind of a Difference.
+type DiffKind int
+
+const (
+	DiffChanged DiffKind = iota // The value differs between A and B
+	DiffRemoved                 // The value is present in A but not in B
+	DiffAdded                   // The value is present in B but not in A
+)
+
+func (k DiffKind) String() string {
+	switch k {
+	case DiffChanged:
+		return "changed"
+	case DiffRemoved:
+		return "removed"
+	case DiffAdded:
+		return "added"
+	default:
+		return "DiffKind(" + strconv.Itoa(int(k)) + ")"
+	}
+}
+
+// Difference is a difference between two values found by Diff.
+type Difference struct {
+	Path string   // The path to the differing value, e.g. `.Users[3].Address.Zip` or `["key"]`; empty for the values themselves
+	Kind DiffKind // Whether the value was changed, removed or added
+	Type string   // The TypeName of the value's static type, e.g. "interface {}" when dynamic types differ
+	A    string   // The rendering of the value in A by Sprint, unless added
+	B    string   // The rendering of the value in B by Sprint, unless removed
+}
+
+// String renders the difference, followed by the values in A and B on lines marked with
+// "-" and "+":
+//
+//	changed .Users[3].Address.Zip (string)
+//		- "8000"
+//		+ "8001"
+func (d Difference) String() string {
+	path := d.Path
+	if path == "" {
+		path = "<root>"
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s (%s)", d.Kind, path, d.Type)
+	if d.Kind != DiffAdded {
+		writeDiffValue(&b, "-", d.A)
+	}
+	if d.Kind != DiffRemoved {
+		writeDiffValue(&b, "+", d.B)
+	}
+	return b.String()
+}
+
+// writeDiffValue writes a value of a Difference on lines of its own, marked with marker.
+func writeDiffValue(b *strings.Builder, marker, value string) {
+	for i, line := range strings.Split(value, "\n") {
+		if i == 0 {
+			fmt.Fprintf(b, "\n\t%s %s", marker, line)
+		} else {
+			fmt.Fprintf(b, "\n\t  %s", line)
+		}
+	}
+}
+
+// FormatDifferences renders a text report of differences found by Diff, one after the
+// other. Returns an empty string if there are none.
+func FormatDifferences(diffs []Difference) string {
+	rendered := make([]string, len(diffs))
+	for i, d := range diffs {
+		rendered[i] = d.String()
+	}
+	return strings.Join(rendered, "\n")
+}
+
+// Diff compares a and b structurally and returns their differences, in the order of the
+// paths at which they are found, or nil if a and b are deeply equal.
+//
+// Structs, including their unexported fields, and arrays are compared field by field and
+// element by element, and pointers and interfaces by the values they refer to. Slices are
+// aligned along their longest common subsequence, so an inserted or removed element is
+// reported as such rather than as a change to every following element; differing elements
+// left between common ones are compared pairwise. The paths of added elements use their
+// index in b, and other paths their index in a. Map entries are compared by key, in the
+// order of Dumper. Entries whose key is not equal to itself, such as a NaN float, cannot
+// be matched by key and are always reported as removed from a and added in b. Functions,
+// channels and unsafe pointers are equal if they are the same.
+//
+// As with reflect.DeepEqual, a nil slice or map differs from an empty one, and a pointer,
+// map or slice that refers back to one being compared is assumed equal, so that cyclic
+// values are compared in finite time.
+func Diff(a, b any) []Difference {
+	d := differ{visiting: make(map[diffVisit]bool)}
+	// Compare through interfaces, as values of different dynamic types may be passed.
+	d.diff("", reflect.ValueOf(&a).Elem(), reflect.ValueOf(&b).Elem())
+	return d.diffs
+}
+
+// diffVisit identifies a pair of pointers, maps or slices being compared, to detect cycles.
+type diffVisit struct {
+	typ  reflect.Type
+	a, b uintptr
+	len  int
+}
+
+// differ is the state of a single comparison by Diff.
+type differ struct {
+	diffs    []Difference
+	visiting map[diffVisit]bool // The pairs of pointers, maps and slices being compared
+}
+
+// report records a difference of the given kind at path between a and b of static type t.
+func (d *differ) report(path string, t reflect.Type, kind DiffKind, a, b reflect.Value) {
+	diff := Difference{Path: path, Kind: kind, Type: TypeName(t)}
+	if kind != DiffAdded {
+		diff.A = Dumper{}.sprintValue(a)
+	}
+	if kind != DiffRemoved {
+		diff.B = Dumper{}.sprintValue(b)
+	}
+	d.diffs = append(d.diffs, diff)
+}
+
+// equal reports whether a and b of the same type have no differences, following the
+// same rules as diff, but returning at the first difference without rendering any.
+func (d *differ) equal(a, b reflect.Value) bool {
+	switch a.Kind() {
+	case reflect.Interface:
+		if a.IsNil() || b.IsNil() {
+			return a.IsNil() && b.IsNil()
+		}
+		return a.Elem().Type() == b.Elem().Type() && d.equal(a.Elem(), b.Elem())
+	case reflect.Pointer:
+		if a.IsNil() || b.IsNil() {
+			return a.IsNil() && b.IsNil()
+		}
+		if !d.enter(a, b, 0) {
+			return true
+		}
+		defer d.leave(a, b, 0)
+		return d.equal(a.Elem(), b.Elem())
+	case reflect.Struct:
+		for i := range a.NumField() {
+			if !d.equal(a.Field(i), b.Field(i)) {
+				return false
+			}
+		}
+		return true
+	case reflect.Array:
+		for i := range a.Len() {
+			if !d.equal(a.Index(i), b.Index(i)) {
+				return false
+			}
+		}
+		return true
+	case reflect.Slice:
+		if a.IsNil() || b.IsNil() {
+			return a.IsNil() && b.IsNil()
+		}
+		// Slices of the same length have no differences only if all elements are aligned.
+		if a.Len() != b.Len() {
+			return false
+		}
+		if !d.enter(a, b, a.Len()) {
+			return true
+		}
+		defer d.leave(a, b, a.Len())
+		for i := range a.Len() {
+			if !d.equal(a.Index(i), b.Index(i)) {
+				return false
+			}
+		}
+		return true
+	case reflect.Map:
+		if a.IsNil() || b.IsNil() {
+			return a.IsNil() && b.IsNil()
+		}
+		if a.Len() != b.Len() {
+			return false
+		}
+		if !d.enter(a, b, 0) {
+			return true
+		}
+		defer d.leave(a, b, 0)
+		for iter := a.MapRange(); iter.Next(); {
+			if !reflexiveMapKey(iter.Key()) {
+				return false
+			}
+			bv := b.MapIndex(iter.Key())
+			if !bv.IsValid() || !d.equal(iter.Value(), bv) {
+				return false
+			}
+		}
+		return true
+	case reflect.Func, reflect.Chan, reflect.UnsafePointer:
+		return a.Pointer() == b.Pointer()
+	default:
+		return equalBasic(a, b)
+	}
+}
+
+// diff records the differences between a and b, which have the same static type.
+func (d *differ) diff(path string, a, b reflect.Value) {
+	t := a.Type()
+	switch a.Kind() {
+	case reflect.Interface:
+		switch {
+		case a.IsNil() && b.IsNil():
+		case a.IsNil() || b.IsNil() || a.Elem().Type() != b.Elem().Type():
+			d.report(path, t, DiffChanged, a.Elem(), b.Elem())
+		default:
+			d.diff(path, a.Elem(), b.Elem())
+		}
+	case reflect.Pointer:
+		switch {
+		case a.IsNil() && b.IsNil():
+		case a.IsNil() || b.IsNil():
+			d.report(path, t, DiffChanged, a, b)
+		default:
+			if d.enter(a, b, 0) {
+				defer d.leave(a, b, 0)
+				d.diff(path, a.Elem(), b.Elem())
+			}
+		}
+	case reflect.Struct:
+		for i := range t.NumField() {
+			d.diff(path+"."+t.Field(i).Name, a.Field(i), b.Field(i))
+		}
+	case reflect.Array:
+		for i := range a.Len() {
+			d.diff(path+"["+strconv.Itoa(i)+"]", a.Index(i), b.Index(i))
+		}
+	case reflect.Slice:
+		switch {
+		case a.IsNil() && b.IsNil():
+		case a.IsNil() || b.IsNil():
+			d.report(path, t, DiffChanged, a, b)
+		default:
+			if d.enter(a, b, a.Len()) {
+				defer d.leave(a, b, a.Len())
+				d.diffSlice(path, a, b)
+			}
+		}
+	case reflect.Map:
+		switch {
+		case a.IsNil() && b.IsNil():
+		case a.IsNil() || b.IsNil():
+			d.report(path, t, DiffChanged, a, b)
+		default:
+			if d.enter(a, b, 0) {
+				defer d.leave(a, b, 0)
+				d.diffMap(path, a, b)
+			}
+		}
+	case reflect.Func, reflect.Chan, reflect.UnsafePointer:
+		if a.Pointer() != b.Pointer() {
+			d.report(path, t, DiffChanged, a, b)
+		}
+	default:
+		if !equalBasic(a, b) {
+			d.report(path, t, DiffChanged, a, b)
+		}
+	}
+}
+
+// diffSlice records the differences between slices a and b, aligned along their longest
+// common subsequence.
+func (d *differ) diffSlice(path string, a, b reflect.Value) {
+	index := func(i int) string { return path + "[" + strconv.Itoa(i) + "]" }
+	elem := a.Type().Elem()
+
+	// i and j advance through a and b; each common pair ends a gap of differing elements.
+	i, j := 0, 0
+	for _, common := range d.alignSlices(a, b) {
+		for ; i < common[0] && j < common[1]; i, j = i+1, j+1 {
+			d.diff(index(i), a.Index(i), b.Index(j))
+		}
+		for ; i < common[0]; i++ {
+			d.report(index(i), elem, DiffRemoved, a.Index(i), reflect.Value{})
+		}
+		for ; j < common[1]; j++ {
+			d.report(index(j), elem, DiffAdded, reflect.Value{}, b.Index(j))
+		}
+		i, j = i+1, j+1
+	}
+}
+
+// alignSlices returns the index pairs of the longest common subsequence of a and b,
+// followed by the pair of their lengths. Beyond maxDiffAlignment, only the latter is
+// returned, so that the slices are compared element by element.
+func (d *differ) alignSlices(a, b reflect.Value) [][2]int {
+	n, m := a.Len(), b.Len()
+	end := [2]int{n, m}
+	if n*m > maxDiffAlignment {
+		return [][2]int{end}
+	}
+
+	// lengths[i][j] is the length of the longest common subsequence of a[i:] and b[j:].
+	equal := make([][]bool, n)
+	lengths := make([][]int, n+1)
+	lengths[n] = make([]int, m+1)
+	for i := n - 1; i >= 0; i-- {
+		equal[i] = make([]bool, m)
+		lengths[i] = make([]int, m+1)
+		for j := m - 1; j >= 0; j-- {
+			equal[i][j] = d.equal(a.Index(i), b.Index(j))
+			if equal[i][j] {
+				lengths[i][j] = lengths[i+1][j+1] + 1
+			} else {
+				lengths[i][j] = max(lengths[i+1][j], lengths[i][j+1])
+			}
+		}
+	}
+
+	var pairs [][2]int
+	for i, j := 0, 0; i < n && j < m; {
+		switch {
+		case equal[i][j]:
+			pairs = append(pairs, [2]int{i, j})
+			i, j = i+1, j+1
+		case lengths[i+1][j] >= lengths[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+	return append(pairs, end)
+}
+
+// diffMap records the differences between maps a and b, by key.
+func (d *differ) diffMap(path string, a, b reflect.Value) {
+	type entry struct {
+		key  reflect.Value
+		text string        // The rendering of key in a path
+		a, b reflect.Value // The values in a and b, invalid if absent
+	}
+	var entries []entry
+	for iter := a.MapRange(); iter.Next(); {
+		e := entry{key: iter.Key(), text: diffMapKey(iter.Key()), a: iter.Value()}
+		if reflexiveMapKey(e.key) {
+			e.b = b.MapIndex(e.key)
+		}
+		entries = append(entries, e)
+	}
+	for iter := b.MapRange(); iter.Next(); {
+		if !reflexiveMapKey(iter.Key()) || !a.MapIndex(iter.Key()).IsValid() {
+			entries = append(entries, entry{key: iter.Key(), text: diffMapKey(iter.Key()), b: iter.Value()})
+		}
+	}
+	// Stable, so that entries of a come first among those with keys rendered alike, such as NaNs.
+	slices.SortStableFunc(entries, func(x, y entry) int {
+		if c := compareMapKeys(x.key, y.key); c != 0 {
+			return c
+		}
+		return strings.Compare(x.text, y.text)
+	})
+
+	elem := a.Type().Elem()
+	for _, e := range entries {
+		keyPath := path + "[" + e.text + "]"
+		switch {
+		case !e.b.IsValid():
+			d.report(keyPath, elem, DiffRemoved, e.a, e.b)
+		case !e.a.IsValid():
+			d.report(keyPath, elem, DiffAdded, e.a, e.b)
+		default:
+			d.diff(keyPath, e.a, e.b)
+		}
+	}
+}
+
+// reflexiveMapKey reports whether the map key k is equal to itself, and can thus be
+// looked up. This only fails for NaN floats, possibly within interfaces, arrays and structs.
+func reflexiveMapKey(k reflect.Value) bool {
+	switch k.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return !math.IsNaN(k.Float())
+	case reflect.Complex64, reflect.Complex128:
+		c := k.Complex()
+		return !math.IsNaN(real(c)) && !math.IsNaN(imag(c))
+	case reflect.Interface:
+		return k.IsNil() || reflexiveMapKey(k.Elem())
+	case reflect.Array:
+		for i := range k.Len() {
+			if !reflexiveMapKey(k.Index(i)) {
+				return false
+			}
+		}
+		return true
+	case reflect.Struct:
+		for i := range k.NumField() {
+			if !reflexiveMapKey(k.Field(i)) {
+				return false
+			}
+		}
+		return true
+	default:
+		return true
+	}
+}
+
+// diffMapKeyReplacer joins the lines of a rendered map key.
+var diffMapKeyReplacer = strings.NewReplacer(",\n}", "}", "{\n", "{", ",\n", ", ")
+
+// diffMapKey renders a map key for a path, on a single line.
+func diffMapKey(key reflect.Value) string {
+	if key.Kind() == reflect.Interface && !key.IsNil() {
+		key = key.Elem()
+	}
+	if literal, ok := basicLiteral(key); ok {
+		return literal
+	}
+	// Quoted strings hold no newlines, so lines are fields and elements to be joined.
+	lines := strings.Split(Dumper{}.sprintValue(key), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimLeft(line, "\t")
+	}
+	return diffMapKeyReplacer.Replace(strings.Join(lines, "\n"))
+}
+
+// enter marks the pair of pointers, maps or slices a and b as being compared, and
+// reports whether it was not already.
+func (d *differ) enter(a, b reflect.Value, length int) bool {
+	visit := diffVisit{typ: a.Type(), a: a.Pointer(), b: b.Pointer(), len: length}
+	if d.visiting[visit] {
+		return false
+	}
+	d.visiting[visit] = true
+	return true
+}
+
+// leave unmarks the pair of pointers, maps or slices a and b marked by enter.
+func (d *differ) leave(a, b reflect.Value, length int) {
+	delete(d.visiting, diffVisit{typ: a.Type(), a: a.Pointer(), b: b.Pointer(), len: length})
+}
+
+// equalBasic reports whether the boolean, numeric or string values a and b are equal.
+func equalBasic(a, b reflect.Value) bool {
+	switch a.Kind() {
+	case reflect.Bool:
+		return a.Bool() == b.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return a.Int() == b.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return a.Uint() == b.Uint()
+	case reflect.Float32, reflect.Float64:
+		return a.Float() == b.Float()
+	case reflect.Complex64, reflect.Complex128:
+		return a.Complex() == b.Complex()
+	case reflect.String:
+		return a.String() == b.String()
+	default:
+		return false
+	}
+}
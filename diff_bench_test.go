@@ -0,0 +1,23 @@
+package commonz_test
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/goosz/commonz"
+)
+
+// BenchmarkDiff_sliceAlignment benchmarks Diff with slices of nested values aligned by
+// their longest common subsequence
+func BenchmarkDiff_sliceAlignment(b *testing.B) {
+	x := make([]diffUser, 200)
+	for i := range x {
+		x[i] = diffUser{Name: strconv.Itoa(i), Address: &diffAddress{City: "Zurich"}, Tags: []string{"a", "b"}}
+	}
+	y := append([]diffUser{{Name: "new"}}, x[:len(x)-1]...)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		commonz.Diff(x, y)
+	}
+}
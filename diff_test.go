@@ -0,0 +1,277 @@
+package commonz_test
+
+import (
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/goosz/commonz"
+	"github.com/stretchr/testify/assert"
+)
+
+type diffAddress struct {
+	City string
+	Zip  NamedString
+}
+
+type diffUser struct {
+	Name    string
+	Address *diffAddress
+	Tags    []string
+	Meta    map[string]any
+	secret  int
+}
+
+type diffDirectory struct {
+	Users []diffUser
+}
+
+func TestDiff(t *testing.T) {
+	users := func() []diffUser {
+		return []diffUser{
+			{Name: "alice", Address: &diffAddress{City: "Zürich", Zip: "8000"}},
+			{Name: "bob", Address: &diffAddress{City: "Bern", Zip: "3000"}},
+			{Name: "carol"},
+			{Name: "dave", Address: &diffAddress{City: "Basel", Zip: "4000"}},
+		}
+	}
+	a := diffDirectory{Users: users()}
+	b := diffDirectory{Users: users()}
+	b.Users[3].Address.Zip = "4001"
+
+	assert.Equal(t, []commonz.Difference{{
+		Path: ".Users[3].Address.Zip",
+		Kind: commonz.DiffChanged,
+		Type: "github.com/goosz/commonz_test.NamedString",
+		A:    `github.com/goosz/commonz_test.NamedString("4000")`,
+		B:    `github.com/goosz/commonz_test.NamedString("4001")`,
+	}}, commonz.Diff(a, b))
+
+	assert.Nil(t, commonz.Diff(a, diffDirectory{Users: users()}))
+}
+
+func TestDiff_values(t *testing.T) {
+	tests := []struct {
+		name     string
+		a, b     any
+		expected []commonz.Difference
+	}{
+		{
+			name:     "equal",
+			a:        map[string][]int{"a": {1}},
+			b:        map[string][]int{"a": {1}},
+			expected: nil,
+		},
+		{
+			name:     "basic value",
+			a:        1,
+			b:        2,
+			expected: []commonz.Difference{{Kind: commonz.DiffChanged, Type: "int", A: "1", B: "2"}},
+		},
+		{
+			name:     "different types",
+			a:        1,
+			b:        "1",
+			expected: []commonz.Difference{{Kind: commonz.DiffChanged, Type: "interface {}", A: "1", B: `"1"`}},
+		},
+		{
+			name:     "nil and non-nil",
+			a:        nil,
+			b:        1.5,
+			expected: []commonz.Difference{{Kind: commonz.DiffChanged, Type: "interface {}", A: "nil", B: "float64(1.5)"}},
+		},
+		{
+			name:     "nil and empty slice",
+			a:        []int(nil),
+			b:        []int{},
+			expected: []commonz.Difference{{Kind: commonz.DiffChanged, Type: "[]int", A: "[]int(nil)", B: "[]int{}"}},
+		},
+		{
+			name: "nil pointer",
+			a:    diffUser{Address: &diffAddress{City: "Bern"}},
+			b:    diffUser{},
+			expected: []commonz.Difference{{
+				Path: ".Address",
+				Kind: commonz.DiffChanged,
+				Type: "*github.com/goosz/commonz_test.diffAddress",
+				A:    "&github.com/goosz/commonz_test.diffAddress{\n\tCity: \"Bern\",\n\tZip: \"\",\n}",
+				B:    "(*github.com/goosz/commonz_test.diffAddress)(nil)",
+			}},
+		},
+		{
+			name:     "unexported field",
+			a:        diffUser{secret: 1},
+			b:        diffUser{secret: 2},
+			expected: []commonz.Difference{{Path: ".secret", Kind: commonz.DiffChanged, Type: "int", A: "1", B: "2"}},
+		},
+		{
+			name: "map entries",
+			a:    map[string]any{"same": 1, "changed": []int{1}, "removed": true},
+			b:    map[string]any{"same": 1, "changed": []int{2}, "added": "x"},
+			expected: []commonz.Difference{
+				{Path: `["added"]`, Kind: commonz.DiffAdded, Type: "interface {}", B: `"x"`},
+				{Path: `["changed"][0]`, Kind: commonz.DiffChanged, Type: "int", A: "1", B: "2"},
+				{Path: `["removed"]`, Kind: commonz.DiffRemoved, Type: "interface {}", A: "true"},
+			},
+		},
+		{
+			name: "struct map keys",
+			a:    map[diffAddress]int{{City: "Bern"}: 1},
+			b:    map[diffAddress]int{{City: "Bern"}: 2},
+			expected: []commonz.Difference{
+				{Path: `[github.com/goosz/commonz_test.diffAddress{City: "Bern", Zip: ""}]`, Kind: commonz.DiffChanged, Type: "int", A: "1", B: "2"},
+			},
+		},
+		{
+			name: "array",
+			a:    [3]int{1, 2, 3},
+			b:    [3]int{1, 5, 3},
+			expected: []commonz.Difference{
+				{Path: "[1]", Kind: commonz.DiffChanged, Type: "int", A: "2", B: "5"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, commonz.Diff(tt.a, tt.b))
+		})
+	}
+}
+
+func TestDiff_sliceAlignment(t *testing.T) {
+	tests := []struct {
+		name     string
+		a, b     []string
+		expected []commonz.Difference
+	}{
+		{
+			name: "insertion",
+			a:    []string{"a", "b", "c"},
+			b:    []string{"a", "x", "b", "c"},
+			expected: []commonz.Difference{
+				{Path: "[1]", Kind: commonz.DiffAdded, Type: "string", B: `"x"`},
+			},
+		},
+		{
+			name: "removal",
+			a:    []string{"a", "b", "c", "d"},
+			b:    []string{"a", "c", "d"},
+			expected: []commonz.Difference{
+				{Path: "[1]", Kind: commonz.DiffRemoved, Type: "string", A: `"b"`},
+			},
+		},
+		{
+			name: "change between common elements",
+			a:    []string{"a", "b", "c"},
+			b:    []string{"a", "x", "c"},
+			expected: []commonz.Difference{
+				{Path: "[1]", Kind: commonz.DiffChanged, Type: "string", A: `"b"`, B: `"x"`},
+			},
+		},
+		{
+			name: "change and insertion",
+			a:    []string{"a", "b", "c"},
+			b:    []string{"x", "y", "b", "c", "z"},
+			expected: []commonz.Difference{
+				{Path: "[0]", Kind: commonz.DiffChanged, Type: "string", A: `"a"`, B: `"x"`},
+				{Path: "[1]", Kind: commonz.DiffAdded, Type: "string", B: `"y"`},
+				{Path: "[4]", Kind: commonz.DiffAdded, Type: "string", B: `"z"`},
+			},
+		},
+		{
+			name: "removal of everything",
+			a:    []string{"a", "b"},
+			b:    []string{},
+			expected: []commonz.Difference{
+				{Path: "[0]", Kind: commonz.DiffRemoved, Type: "string", A: `"a"`},
+				{Path: "[1]", Kind: commonz.DiffRemoved, Type: "string", A: `"b"`},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, commonz.Diff(tt.a, tt.b))
+		})
+	}
+}
+
+func TestDiff_nestedSliceAlignment(t *testing.T) {
+	a := []diffUser{{Name: "alice"}, {Name: "bob", Tags: []string{"x"}}}
+	b := []diffUser{{Name: "zoe"}, {Name: "alice"}, {Name: "bob", Tags: []string{"y"}}}
+
+	assert.Equal(t, []commonz.Difference{
+		{Path: "[0]", Kind: commonz.DiffAdded, Type: "github.com/goosz/commonz_test.diffUser",
+			B: commonz.Sprint(diffUser{Name: "zoe"})},
+		{Path: "[1].Tags[0]", Kind: commonz.DiffChanged, Type: "string", A: `"x"`, B: `"y"`},
+	}, commonz.Diff(a, b))
+}
+
+func TestDiff_cycles(t *testing.T) {
+	a := &dumpNode{Value: 1}
+	a.Next = &dumpNode{Value: 2, Next: a}
+	b := &dumpNode{Value: 1}
+	b.Next = &dumpNode{Value: 3, Next: b}
+
+	assert.Equal(t, []commonz.Difference{
+		{Path: ".Next.Value", Kind: commonz.DiffChanged, Type: "int", A: "2", B: "3"},
+	}, commonz.Diff(a, b))
+
+	b.Next.Value = 2
+	assert.Nil(t, commonz.Diff(a, b))
+}
+
+func TestDiff_nanMapKeys(t *testing.T) {
+	a := map[float64]string{math.NaN(): "x", 1: "y"}
+	b := map[float64]string{math.NaN(): "x", 1: "y"}
+
+	assert.Equal(t, []commonz.Difference{
+		{Path: "[math.NaN()]", Kind: commonz.DiffRemoved, Type: "string", A: `"x"`},
+		{Path: "[math.NaN()]", Kind: commonz.DiffAdded, Type: "string", B: `"x"`},
+	}, commonz.Diff(a, b))
+
+	// Within slices, such maps are never aligned with each other.
+	assert.Equal(t, []commonz.Difference{
+		{Path: "[0][math.NaN()]", Kind: commonz.DiffRemoved, Type: "string", A: `"x"`},
+		{Path: "[0][math.NaN()]", Kind: commonz.DiffAdded, Type: "string", B: `"x"`},
+	}, commonz.Diff([]map[float64]string{a}, []map[float64]string{b}))
+}
+
+func TestDiff_sliceAlignmentOfNestedValues(t *testing.T) {
+	a := make([]diffDirectory, 100)
+	for i := range a {
+		a[i].Users = []diffUser{{Name: strconv.Itoa(i), Tags: []string{"x"}, Meta: map[string]any{"i": i}}}
+	}
+	b := slices.Clone(a)
+	b[50] = diffDirectory{Users: []diffUser{{Name: "new"}}}
+
+	diffs := commonz.Diff(a, b)
+	assert.Len(t, diffs, 3)
+	assert.Equal(t, "[50].Users[0].Name", diffs[0].Path)
+}
+
+func TestFormatDifferences(t *testing.T) {
+	a := diffUser{Name: "alice", Tags: []string{"admin"}}
+	b := diffUser{Name: "alicia", Tags: []string{"admin", "ops"}, Address: &diffAddress{City: "Bern"}}
+
+	expected := strings.Join([]string{
+		`changed .Name (string)`,
+		`	- "alice"`,
+		`	+ "alicia"`,
+		`changed .Address (*github.com/goosz/commonz_test.diffAddress)`,
+		`	- (*github.com/goosz/commonz_test.diffAddress)(nil)`,
+		`	+ &github.com/goosz/commonz_test.diffAddress{`,
+		`	  	City: "Bern",`,
+		`	  	Zip: "",`,
+		`	  }`,
+		`added .Tags[1] (string)`,
+		`	+ "ops"`,
+	}, "\n")
+	assert.Equal(t, expected, commonz.FormatDifferences(commonz.Diff(a, b)))
+
+	assert.Equal(t, "changed <root> (int)\n\t- 1\n\t+ 2", commonz.FormatDifferences(commonz.Diff(1, 2)))
+	assert.Empty(t, commonz.FormatDifferences(nil))
+}
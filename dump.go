@@ -0,0 +1,418 @@
+package commonz
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Dumper renders values as Go-syntax-like literals, with one field or element per line
+// and types rendered by a TypeFormatter, for use in test failure messages and golden files:
+//
+//	&github.com/user/app.User{
+//		Name: "alice",
+//		Tags: []string{
+//			"admin",
+//		},
+//		Manager: (*github.com/user/app.User)(nil),
+//	}
+//
+// Map entries are sorted by key. A pointer, map or slice that refers back to a value
+// being rendered is rendered as a marker such as "<cycle *github.com/user/app.Node>",
+// and values nested deeper than MaxDepth are rendered as "{...}". Function values are
+// rendered with the name of the function, and channels with their capacity; other
+// values are rendered without calling their methods, such as String.
+//
+// The zero value renders with tab indentation, no depth limit and unexported fields
+// included.
+type Dumper struct {
+	// Types renders the names of types.
+	Types TypeFormatter
+
+	// Indent is the indentation of each nesting level; defaults to a tab.
+	Indent string
+
+	// MaxDepth, if positive, limits how deeply nested structs, arrays, slices and maps
+	// are rendered, as for TypeFormatter.
+	MaxDepth int
+
+	// OmitUnexported leaves out the unexported fields of structs.
+	OmitUnexported bool
+}
+
+// Dump writes the Go-syntax rendering of v to w, using a zero Dumper.
+func Dump(w io.Writer, v any) error {
+	return Dumper{}.Dump(w, v)
+}
+
+// Sprint returns the Go-syntax rendering of v, using a zero Dumper.
+func Sprint(v any) string {
+	return Dumper{}.Sprint(v)
+}
+
+// Dump writes the Go-syntax rendering of v to w, followed by a newline.
+func (d Dumper) Dump(w io.Writer, v any) error {
+	_, err := io.WriteString(w, d.Sprint(v)+"\n")
+	return err
+}
+
+// Sprint returns the Go-syntax rendering of v.
+func (d Dumper) Sprint(v any) string {
+	return d.sprintValue(reflect.ValueOf(v))
+}
+
+// sprintValue returns the Go-syntax rendering of v, which may have been obtained through
+// unexported fields.
+func (d Dumper) sprintValue(v reflect.Value) string {
+	if d.Indent == "" {
+		d.Indent = "\t"
+	}
+	if d.MaxDepth <= 0 {
+		d.MaxDepth = -1 // Never reaches zero
+	}
+	s := dumpState{Dumper: d, visiting: make(map[dumpVisit]bool)}
+	s.value(v, false, d.MaxDepth)
+	return s.b.String()
+}
+
+// dumpVisit identifies a pointer, map or slice being rendered, to detect cycles.
+type dumpVisit struct {
+	typ reflect.Type
+	ptr uintptr
+	len int
+}
+
+// dumpState is the state of a single rendering by a Dumper.
+type dumpState struct {
+	Dumper
+	b        strings.Builder
+	level    int                // The current indentation level
+	visiting map[dumpVisit]bool // The pointers, maps and slices being rendered
+}
+
+// value renders v. If implicit is set, the type of v is implied by the enclosing
+// value, so basic values are rendered without a conversion to their type. Composite
+// values nested more than depth levels deep are elided.
+func (s *dumpState) value(v reflect.Value, implicit bool, depth int) {
+	if !v.IsValid() {
+		s.b.WriteString("nil")
+		return
+	}
+	t := v.Type()
+	switch v.Kind() {
+	case reflect.Interface:
+		if v.IsNil() {
+			s.b.WriteString("nil")
+			return
+		}
+		s.value(v.Elem(), false, depth)
+	case reflect.Pointer:
+		if v.IsNil() {
+			s.b.WriteString(s.conversion(t, "nil"))
+			return
+		}
+		if !s.enter(v, 0) {
+			return
+		}
+		defer s.leave(v, 0)
+		s.b.WriteString("&")
+		if literal, ok := basicLiteral(v.Elem()); ok {
+			// There is no literal for a pointer to a basic value, but &T(x) reads well.
+			s.b.WriteString(s.conversion(v.Elem().Type(), literal))
+			return
+		}
+		s.value(v.Elem(), false, depth)
+	case reflect.Struct:
+		s.structValue(v, depth)
+	case reflect.Array:
+		s.sequence(v, depth)
+	case reflect.Slice:
+		if v.IsNil() {
+			s.b.WriteString(s.conversion(t, "nil"))
+			return
+		}
+		if !s.enter(v, v.Len()) {
+			return
+		}
+		defer s.leave(v, v.Len())
+		s.sequence(v, depth)
+	case reflect.Map:
+		if v.IsNil() {
+			s.b.WriteString(s.conversion(t, "nil"))
+			return
+		}
+		if !s.enter(v, 0) {
+			return
+		}
+		defer s.leave(v, 0)
+		s.mapValue(v, depth)
+	case reflect.Func:
+		if v.IsNil() {
+			s.b.WriteString(s.conversion(t, "nil"))
+			return
+		}
+		name := "?"
+		if f := runtime.FuncForPC(v.Pointer()); f != nil {
+			name = strings.TrimSuffix(f.Name(), methodValueSuffix)
+		}
+		s.b.WriteString(s.conversion(t, name))
+	case reflect.Chan:
+		if v.IsNil() {
+			s.b.WriteString(s.conversion(t, "nil"))
+			return
+		}
+		fmt.Fprintf(&s.b, "make(%s, %d)", s.Types.Format(t), v.Cap())
+	case reflect.UnsafePointer:
+		s.b.WriteString(s.conversion(t, fmt.Sprintf("%#x", v.Pointer())))
+	default:
+		literal, _ := basicLiteral(v)
+		plain := implicit || t.Name() != "" && t.PkgPath() == "" && isUntypedDefault(t.Kind())
+		if isNonFinite(v) {
+			// Rendered as a call of type float64 or complex128 rather than as a constant
+			plain = t == reflect.TypeFor[float64]() || t == reflect.TypeFor[complex128]()
+		}
+		if plain {
+			s.b.WriteString(literal)
+		} else {
+			s.b.WriteString(s.conversion(t, literal))
+		}
+	}
+}
+
+// structValue renders a struct, with one field per line.
+func (s *dumpState) structValue(v reflect.Value, depth int) {
+	t := v.Type()
+	s.b.WriteString(s.Types.Format(t))
+	var fields []int
+	for i := range t.NumField() {
+		if t.Field(i).IsExported() || !s.OmitUnexported {
+			fields = append(fields, i)
+		}
+	}
+	if !s.open(len(fields), depth) {
+		return
+	}
+	for _, i := range fields {
+		s.newline()
+		s.b.WriteString(t.Field(i).Name)
+		s.b.WriteString(": ")
+		s.value(v.Field(i), true, depth-1)
+		s.b.WriteString(",")
+	}
+	s.close()
+}
+
+// sequence renders an array or slice, with one element per line.
+func (s *dumpState) sequence(v reflect.Value, depth int) {
+	s.b.WriteString(s.Types.Format(v.Type()))
+	if !s.open(v.Len(), depth) {
+		return
+	}
+	for i := range v.Len() {
+		s.newline()
+		s.value(v.Index(i), true, depth-1)
+		s.b.WriteString(",")
+	}
+	s.close()
+}
+
+// mapValue renders a map, with one entry per line, sorted by key.
+func (s *dumpState) mapValue(v reflect.Value, depth int) {
+	s.b.WriteString(s.Types.Format(v.Type()))
+	if !s.open(v.Len(), depth) {
+		return
+	}
+
+	type entry struct {
+		key   reflect.Value
+		value reflect.Value
+		text  string // The rendering of key
+	}
+	entries := make([]entry, 0, v.Len())
+	for iter := v.MapRange(); iter.Next(); {
+		key := dumpState{Dumper: s.Dumper, level: s.level, visiting: s.visiting}
+		key.value(iter.Key(), true, depth-1)
+		entries = append(entries, entry{iter.Key(), iter.Value(), key.b.String()})
+	}
+	slices.SortFunc(entries, func(a, b entry) int {
+		if c := compareMapKeys(a.key, b.key); c != 0 {
+			return c
+		}
+		return strings.Compare(a.text, b.text)
+	})
+
+	for _, e := range entries {
+		s.newline()
+		s.b.WriteString(e.text)
+		s.b.WriteString(": ")
+		s.value(e.value, true, depth-1)
+		s.b.WriteString(",")
+	}
+	s.close()
+}
+
+// open writes the opening brace of a composite value with n fields or elements, and
+// reports whether they should be rendered: they are not if there are none, or if the
+// depth limit is reached, in which case the value is closed as well.
+func (s *dumpState) open(n int, depth int) bool {
+	switch {
+	case n == 0:
+		s.b.WriteString("{}")
+		return false
+	case depth == 0:
+		s.b.WriteString("{...}")
+		return false
+	}
+	s.b.WriteString("{")
+	s.level++
+	return true
+}
+
+// close writes the closing brace of a composite value opened by open.
+func (s *dumpState) close() {
+	s.level--
+	s.newline()
+	s.b.WriteString("}")
+}
+
+// newline starts a new line at the current indentation level.
+func (s *dumpState) newline() {
+	s.b.WriteString("\n")
+	s.b.WriteString(strings.Repeat(s.Indent, s.level))
+}
+
+// enter marks the pointer, map or slice v as being rendered, and reports whether it
+// was not already, in which case a cycle marker is rendered in its place.
+func (s *dumpState) enter(v reflect.Value, length int) bool {
+	visit := dumpVisit{typ: v.Type(), ptr: v.Pointer(), len: length}
+	if s.visiting[visit] {
+		s.b.WriteString("<cycle " + s.Types.Format(v.Type()) + ">")
+		return false
+	}
+	s.visiting[visit] = true
+	return true
+}
+
+// leave unmarks the pointer, map or slice v marked by enter.
+func (s *dumpState) leave(v reflect.Value, length int) {
+	delete(s.visiting, dumpVisit{typ: v.Type(), ptr: v.Pointer(), len: length})
+}
+
+// conversion renders a conversion of a literal to type t, e.g. "time.Duration(5)" or
+// "(*int)(nil)".
+func (s *dumpState) conversion(t reflect.Type, literal string) string {
+	name := s.Types.Format(t)
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Func, reflect.Chan, reflect.UnsafePointer:
+		if t.Name() == "" {
+			name = "(" + name + ")"
+		}
+	}
+	return name + "(" + literal + ")"
+}
+
+// basicLiteral returns the literal of a boolean, numeric or string value, if v is one.
+func basicLiteral(v reflect.Value) (string, bool) {
+	switch v.Kind() {
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), true
+	case reflect.Uintptr:
+		return fmt.Sprintf("%#x", v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return floatLiteral(v.Float(), v.Type().Bits()), true
+	case reflect.Complex64, reflect.Complex128:
+		c := v.Complex()
+		if isNonFinite(v) {
+			bits := v.Type().Bits() / 2
+			return "complex(" + floatLiteral(real(c), bits) + ", " + floatLiteral(imag(c), bits) + ")", true
+		}
+		return strconv.FormatComplex(c, 'g', -1, v.Type().Bits()), true
+	case reflect.String:
+		return strconv.Quote(v.String()), true
+	default:
+		return "", false
+	}
+}
+
+// floatLiteral returns the literal of a float with the given number of bits, or for NaN
+// and infinities, which have none, the call of package math returning them.
+func floatLiteral(f float64, bits int) string {
+	switch {
+	case math.IsNaN(f):
+		return "math.NaN()"
+	case math.IsInf(f, 1):
+		return "math.Inf(1)"
+	case math.IsInf(f, -1):
+		return "math.Inf(-1)"
+	default:
+		return strconv.FormatFloat(f, 'g', -1, bits)
+	}
+}
+
+// isNonFinite reports whether v is a float that is NaN or infinite, or a complex number
+// with such a part.
+func isNonFinite(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Float32, reflect.Float64:
+		f := v.Float()
+		return math.IsNaN(f) || math.IsInf(f, 0)
+	case reflect.Complex64, reflect.Complex128:
+		c := v.Complex()
+		return math.IsNaN(real(c)) || math.IsInf(real(c), 0) || math.IsNaN(imag(c)) || math.IsInf(imag(c), 0)
+	default:
+		return false
+	}
+}
+
+// isUntypedDefault reports whether kind is the default type of untyped constants whose
+// literals are unambiguous, so that a value of that predeclared type can be rendered
+// without a conversion.
+func isUntypedDefault(kind reflect.Kind) bool {
+	return kind == reflect.Bool || kind == reflect.Int || kind == reflect.String
+}
+
+// compareMapKeys orders map keys of the same kind by value, and keys of different kinds,
+// as in maps with interface keys, by kind. Returns 0 for keys that are not ordered this
+// way, such as structs, which are then ordered by their rendering.
+func compareMapKeys(a, b reflect.Value) int {
+	if a.Kind() == reflect.Interface {
+		a, b = a.Elem(), b.Elem()
+	}
+	if !a.IsValid() || !b.IsValid() {
+		return cmp.Compare(boolToInt(a.IsValid()), boolToInt(b.IsValid()))
+	}
+	if a.Kind() != b.Kind() {
+		return cmp.Compare(a.Kind(), b.Kind())
+	}
+	switch a.Kind() {
+	case reflect.Bool:
+		return cmp.Compare(boolToInt(a.Bool()), boolToInt(b.Bool()))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return cmp.Compare(a.Int(), b.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return cmp.Compare(a.Uint(), b.Uint())
+	case reflect.Float32, reflect.Float64:
+		return cmp.Compare(a.Float(), b.Float())
+	case reflect.String:
+		return cmp.Compare(a.String(), b.String())
+	default:
+		return 0
+	}
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,207 @@
+package commonz_test
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/goosz/commonz"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type dumpAddress struct {
+	City string
+	Zip  NamedString
+}
+
+type dumpUser struct {
+	Name     string
+	Age      int
+	Score    float64
+	Tags     []string
+	Address  *dumpAddress
+	Manager  *dumpUser
+	Extra    any
+	Timeout  time.Duration
+	internal bool
+}
+
+type dumpNode struct {
+	Value int
+	Next  *dumpNode
+}
+
+func dumpHandler() {}
+
+func TestSprint(t *testing.T) {
+	user := &dumpUser{
+		Name:    "alice",
+		Age:     42,
+		Score:   1,
+		Tags:    []string{"admin", "ops"},
+		Address: &dumpAddress{City: "Zürich", Zip: "8000"},
+		Extra:   uint8(7),
+		Timeout: 5 * time.Second,
+	}
+	expected := strings.Join([]string{
+		`&github.com/goosz/commonz_test.dumpUser{`,
+		`	Name: "alice",`,
+		`	Age: 42,`,
+		`	Score: 1,`,
+		`	Tags: []string{`,
+		`		"admin",`,
+		`		"ops",`,
+		`	},`,
+		`	Address: &github.com/goosz/commonz_test.dumpAddress{`,
+		`		City: "Zürich",`,
+		`		Zip: "8000",`,
+		`	},`,
+		`	Manager: (*github.com/goosz/commonz_test.dumpUser)(nil),`,
+		`	Extra: uint8(7),`,
+		`	Timeout: 5000000000,`,
+		`	internal: false,`,
+		`}`,
+	}, "\n")
+	assert.Equal(t, expected, commonz.Sprint(user))
+}
+
+func TestSprint_values(t *testing.T) {
+	var nilFunc func()
+	tests := []struct {
+		name     string
+		input    any
+		expected string
+	}{
+		{"nil", nil, "nil"},
+		{"int", 42, "42"},
+		{"string", "a\"b", `"a\"b"`},
+		{"bool", true, "true"},
+		{"float", 1.5, "float64(1.5)"},
+		{"complex", 1 + 2i, "complex128((1+2i))"},
+		{"NaN", math.NaN(), "math.NaN()"},
+		{"positive infinity", math.Inf(1), "math.Inf(1)"},
+		{"negative infinity", float32(math.Inf(-1)), "float32(math.Inf(-1))"},
+		{"complex NaN", complex(math.NaN(), 1), "complex(math.NaN(), 1)"},
+		{"complex64 infinity", complex64(complex(0, math.Inf(1))), "complex64(complex(0, math.Inf(1)))"},
+		{"infinite elements", []float32{1, float32(math.Inf(1))}, "[]float32{\n\t1,\n\tfloat32(math.Inf(1)),\n}"},
+		{"sized int", int8(-3), "int8(-3)"},
+		{"uintptr", uintptr(255), "uintptr(0xff)"},
+		{"named basic", NamedString("x"), `github.com/goosz/commonz_test.NamedString("x")`},
+		{"standard library named basic", time.Second, "time.Duration(1000000000)"},
+		{"pointer to basic", new(int), "&int(0)"},
+		{"nil pointer", (*int)(nil), "(*int)(nil)"},
+		{"nil slice", []int(nil), "[]int(nil)"},
+		{"empty slice", []int{}, "[]int{}"},
+		{"nil map", map[string]int(nil), "map[string]int(nil)"},
+		{"empty struct", struct{}{}, "struct {}{}"},
+		{"nil named slice", NamedSlice(nil), "github.com/goosz/commonz_test.NamedSlice(nil)"},
+		{"nil function", nilFunc, "(func())(nil)"},
+		{"function", dumpHandler, "(func())(github.com/goosz/commonz_test.dumpHandler)"},
+		{"channel", make(chan int, 3), "make(chan int, 3)"},
+		{"nil channel", (chan<- int)(nil), "(chan<- int)(nil)"},
+		{"array", [2]bool{true, false}, "[2]bool{\n\ttrue,\n\tfalse,\n}"},
+		{"interface elements", []any{1, "a", nil, 2.5}, "[]interface {}{\n\t1,\n\t\"a\",\n\tnil,\n\tfloat64(2.5),\n}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, commonz.Sprint(tt.input))
+		})
+	}
+}
+
+func TestSprint_sortedMapKeys(t *testing.T) {
+	assert.Equal(t, "map[int]string{\n\t2: \"two\",\n\t10: \"ten\",\n\t100: \"hundred\",\n}",
+		commonz.Sprint(map[int]string{100: "hundred", 2: "two", 10: "ten"}))
+
+	assert.Equal(t, "map[string]bool{\n\t\"a\": true,\n\t\"b\": false,\n\t\"c\": true,\n}",
+		commonz.Sprint(map[string]bool{"c": true, "a": true, "b": false}))
+
+	assert.Equal(t, "map[interface {}]int{\n\tnil: 0,\n\t1: 1,\n\t\"x\": 2,\n}",
+		commonz.Sprint(map[any]int{"x": 2, 1: 1, nil: 0}))
+
+	assert.Equal(t, strings.Join([]string{
+		`map[github.com/goosz/commonz_test.dumpAddress]int{`,
+		`	github.com/goosz/commonz_test.dumpAddress{`,
+		`		City: "a",`,
+		`		Zip: "1",`,
+		`	}: 1,`,
+		`	github.com/goosz/commonz_test.dumpAddress{`,
+		`		City: "b",`,
+		`		Zip: "0",`,
+		`	}: 2,`,
+		`}`,
+	}, "\n"), commonz.Sprint(map[dumpAddress]int{{"b", "0"}: 2, {"a", "1"}: 1}))
+}
+
+func TestSprint_cycles(t *testing.T) {
+	node := &dumpNode{Value: 1}
+	node.Next = &dumpNode{Value: 2, Next: node}
+	assert.Equal(t, strings.Join([]string{
+		`&github.com/goosz/commonz_test.dumpNode{`,
+		`	Value: 1,`,
+		`	Next: &github.com/goosz/commonz_test.dumpNode{`,
+		`		Value: 2,`,
+		`		Next: <cycle *github.com/goosz/commonz_test.dumpNode>,`,
+		`	},`,
+		`}`,
+	}, "\n"), commonz.Sprint(node))
+
+	m := map[string]any{}
+	m["self"] = m
+	assert.Equal(t, "map[string]interface {}{\n\t\"self\": <cycle map[string]interface {}>,\n}", commonz.Sprint(m))
+
+	// A value referenced twice without a cycle is rendered twice.
+	shared := &dumpAddress{City: "x"}
+	assert.NotContains(t, commonz.Sprint([]*dumpAddress{shared, shared}), "cycle")
+}
+
+func TestDumper(t *testing.T) {
+	user := dumpUser{Name: "bob", Address: &dumpAddress{City: "Bern"}, internal: true}
+
+	t.Run("depth", func(t *testing.T) {
+		dumper := commonz.Dumper{MaxDepth: 1, OmitUnexported: true, Types: commonz.TypeFormatter{Qualifier: commonz.QualifyNone}}
+		assert.Equal(t, strings.Join([]string{
+			`dumpUser{`,
+			`	Name: "bob",`,
+			`	Age: 0,`,
+			`	Score: 0,`,
+			`	Tags: []string(nil),`,
+			`	Address: &dumpAddress{...},`,
+			`	Manager: (*dumpUser)(nil),`,
+			`	Extra: nil,`,
+			`	Timeout: 0,`,
+			`}`,
+		}, "\n"), dumper.Sprint(user))
+	})
+
+	t.Run("no depth limit by default", func(t *testing.T) {
+		var nested any = 1
+		for range 32 {
+			nested = []any{nested}
+		}
+		assert.Equal(t, commonz.Sprint(nested), commonz.Dumper{MaxDepth: -1}.Sprint(nested))
+		assert.NotContains(t, commonz.Sprint(nested), "{...}")
+		assert.Contains(t, commonz.Dumper{MaxDepth: 16}.Sprint(nested), "{...}")
+	})
+
+	t.Run("indent and qualifier", func(t *testing.T) {
+		dumper := commonz.Dumper{Indent: "  ", Types: commonz.TypeFormatter{Qualifier: commonz.QualifyPackageName}}
+		assert.Equal(t, "[]*commonz_test.dumpAddress{\n  &commonz_test.dumpAddress{\n    City: \"Bern\",\n    Zip: \"\",\n  },\n}",
+			dumper.Sprint([]*dumpAddress{user.Address}))
+	})
+
+	t.Run("unexported fields", func(t *testing.T) {
+		assert.Contains(t, commonz.Sprint(user), "internal: true,")
+		assert.NotContains(t, commonz.Dumper{OmitUnexported: true}.Sprint(user), "internal")
+	})
+
+	t.Run("dump", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, commonz.Dump(&buf, []int{1}))
+		assert.Equal(t, "[]int{\n\t1,\n}\n", buf.String())
+	})
+}
@@ -0,0 +1,42 @@
+package commonz_test
+
+import (
+	"fmt"
+
+	"github.com/goosz/commonz"
+)
+
+// ExampleDiff shows the structured differences between two values and their text report.
+func ExampleDiff() {
+	type Config struct {
+		Hosts   []string
+		Limits  map[string]int
+		Verbose bool
+	}
+	before := Config{Hosts: []string{"a", "b", "c"}, Limits: map[string]int{"cpu": 2, "mem": 512}}
+	after := Config{Hosts: []string{"a", "c", "d"}, Limits: map[string]int{"cpu": 4, "mem": 512}, Verbose: true}
+
+	diffs := commonz.Diff(before, after)
+	for _, d := range diffs {
+		fmt.Printf("%s %s\n", d.Kind, d.Path)
+	}
+	fmt.Println()
+	fmt.Println(commonz.FormatDifferences(diffs))
+
+	// Output:
+	// removed .Hosts[1]
+	// added .Hosts[2]
+	// changed .Limits["cpu"]
+	// changed .Verbose
+	//
+	// removed .Hosts[1] (string)
+	// 	- "b"
+	// added .Hosts[2] (string)
+	// 	+ "d"
+	// changed .Limits["cpu"] (int)
+	// 	- 2
+	// 	+ 4
+	// changed .Verbose (bool)
+	// 	- false
+	// 	+ true
+}
@@ -0,0 +1,32 @@
+package commonz_test
+
+import (
+	"os"
+
+	"github.com/goosz/commonz"
+)
+
+// ExampleDump shows the Go-syntax rendering of a nested value.
+func ExampleDump() {
+	type Item struct {
+		SKU      string
+		Quantity int
+	}
+	order := map[string][]*Item{
+		"bob":   {{SKU: "B-2", Quantity: 1}},
+		"alice": nil,
+	}
+
+	_ = commonz.Dump(os.Stdout, order)
+
+	// Output:
+	// map[string][]*github.com/goosz/commonz_test.Item{
+	// 	"alice": []*github.com/goosz/commonz_test.Item(nil),
+	// 	"bob": []*github.com/goosz/commonz_test.Item{
+	// 		&github.com/goosz/commonz_test.Item{
+	// 			SKU: "B-2",
+	// 			Quantity: 1,
+	// 		},
+	// 	},
+	// }
+}
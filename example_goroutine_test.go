@@ -0,0 +1,26 @@
+package commonz_test
+
+import (
+	"fmt"
+
+	"github.com/goosz/commonz"
+)
+
+// ExampleGo demonstrates inspecting where a tracked goroutine was started
+func ExampleGo() {
+	release := make(chan struct{})
+	id := commonz.GoWithLabels("poller", map[string]string{"queue": "jobs"}, func() {
+		<-release
+	})
+
+	info, _ := commonz.TrackedGoroutine(id)
+	fmt.Printf("Name: %s\n", info.Name)
+	fmt.Printf("Labels: %v\n", info.Labels)
+	fmt.Printf("Created by: %s\n", info.Origin)
+	close(release)
+
+	// Output:
+	// Name: poller
+	// Labels: map[queue:jobs]
+	// Created by: github.com/goosz/commonz_test.ExampleGo
+}
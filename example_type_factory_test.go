@@ -0,0 +1,25 @@
+package commonz_test
+
+import (
+	"fmt"
+
+	"github.com/goosz/commonz"
+)
+
+// ExampleTypeFactory shows how to encode and decode values of an interface type as
+// polymorphic JSON.
+func ExampleTypeFactory() {
+	var shapes commonz.TypeFactory[Shape]
+	_, _ = commonz.RegisterImplementation[Shape, *Circle](&shapes)
+	_ = shapes.Register("square", func() Shape { return Square{} })
+
+	data, _ := shapes.Marshal(Square{Side: 2})
+	fmt.Println(string(data))
+
+	shape, _ := shapes.Unmarshal([]byte(`{"type":"*github.com/goosz/commonz_test.Circle","value":{"radius":1}}`))
+	fmt.Printf("%T %.2f\n", shape, shape.Area())
+
+	// Output:
+	// {"type":"square","value":{"side":2}}
+	// *commonz_test.Circle 3.14
+}
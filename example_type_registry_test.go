@@ -0,0 +1,30 @@
+package commonz_test
+
+import (
+	"fmt"
+
+	"github.com/goosz/commonz"
+)
+
+// ExampleTypeRegistry shows how to resolve type names, such as ones read from
+// configuration, back into types.
+func ExampleTypeRegistry() {
+	type Event struct {
+		Name string
+	}
+
+	var registry commonz.TypeRegistry
+	name, _ := commonz.RegisterType[Event](&registry)
+	fmt.Println("Registered:", name)
+
+	t, err := registry.Lookup("map[string][]*" + name)
+	fmt.Println("Resolved:", t, err)
+
+	_, err = registry.Lookup("github.com/goosz/commonz_test.Unknown")
+	fmt.Println(err)
+
+	// Output:
+	// Registered: github.com/goosz/commonz_test.Event
+	// Resolved: map[string][]*commonz_test.Event <nil>
+	// type "github.com/goosz/commonz_test.Unknown": unknown type github.com/goosz/commonz_test.Unknown
+}
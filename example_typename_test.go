@@ -2,6 +2,7 @@ package commonz_test
 
 import (
 	"fmt"
+	"os"
 	"reflect"
 
 	"github.com/goosz/commonz"
@@ -93,3 +94,38 @@ func ExampleTypeName_channels() {
 	// Receive-only channel: <-chan string
 	// Send-only channel: chan<- bool
 }
+
+// ExampleTypeFormatter shows how to configure the qualification of type names.
+func ExampleTypeFormatter() {
+	type User struct {
+		ID   int
+		Name string
+	}
+	typ := reflect.TypeOf(map[string][]*User{})
+
+	short := commonz.TypeFormatter{Qualifier: commonz.QualifyPackageName}
+	aliased := commonz.TypeFormatter{PackageAliases: map[string]string{
+		"github.com/goosz/commonz_test": "app",
+	}}
+
+	fmt.Printf("Default: %s\n", commonz.TypeName(typ))
+	fmt.Printf("Package name: %s\n", short.Format(typ))
+	fmt.Printf("Aliased: %s\n", aliased.Format(typ))
+
+	// Output:
+	// Default: map[string][]*github.com/goosz/commonz_test.User
+	// Package name: map[string][]*commonz_test.User
+	// Aliased: map[string][]*app.User
+}
+
+// ExampleTypeNameOf shows TypeNameOf and TypeNameOfValue, which need no reflect at the call site.
+func ExampleTypeNameOf() {
+	var err error = os.ErrNotExist
+
+	fmt.Printf("Static type: %s\n", commonz.TypeNameOf[error]())
+	fmt.Printf("Dynamic type: %s\n", commonz.TypeNameOfValue(err))
+
+	// Output:
+	// Static type: error
+	// Dynamic type: *errors.errorString
+}
@@ -0,0 +1,39 @@
+package commonz
+
+import (
+	"context"
+	"runtime/trace"
+)
+
+// The runtime/trace functions creating tasks and regions, replaced in tests to observe
+// the names passed to them.
+var (
+	newTraceTask     = trace.NewTask
+	startTraceRegion = trace.StartRegion
+)
+
+// StartCallerTask creates a runtime/trace task whose type is the CallerInfo of the
+// function calling StartCallerTask, e.g. "github.com/user/package.(*Server).Handle".
+//
+// Returns a context carrying the new task, which should be passed to StartCallerRegion
+// and other trace calls made on behalf of the task, and a function that ends the task,
+// suitable for use with defer:
+//
+//	ctx, end := commonz.StartCallerTask(ctx)
+//	defer end()
+func StartCallerTask(ctx context.Context) (context.Context, func()) {
+	ctx, task := newTraceTask(ctx, GetCaller(ParentCaller).String())
+	return ctx, task.End
+}
+
+// StartCallerRegion starts a runtime/trace region whose type is the CallerInfo of the
+// function calling StartCallerRegion, and returns a function that ends the region,
+// suitable for use with defer:
+//
+//	defer commonz.StartCallerRegion(ctx)()
+//
+// As with [trace.StartRegion], the returned function must be called from the same
+// goroutine that started the region.
+func StartCallerRegion(ctx context.Context) func() {
+	return startTraceRegion(ctx, GetCaller(ParentCaller).String()).End
+}
@@ -0,0 +1,64 @@
+package commonz_test
+
+import (
+	"bytes"
+	"context"
+	"runtime/trace"
+	"testing"
+
+	"github.com/goosz/commonz"
+	"github.com/stretchr/testify/require"
+)
+
+// captureExecutionTrace runs fn with execution tracing enabled and returns the raw trace.
+func captureExecutionTrace(t *testing.T, fn func()) []byte {
+	t.Helper()
+	if trace.IsEnabled() {
+		t.Skip("execution tracing already enabled")
+	}
+	var buf bytes.Buffer
+	require.NoError(t, trace.Start(&buf))
+	fn()
+	trace.Stop()
+	return buf.Bytes()
+}
+
+func TestStartCallerTask(t *testing.T) {
+	tasks, regions, restore := commonz.RecordTraceNames()
+	t.Cleanup(restore)
+
+	raw := captureExecutionTrace(t, func() {
+		tracedTask(context.Background())
+	})
+	require.NotEmpty(t, raw)
+	require.Equal(t, []string{"github.com/goosz/commonz_test.tracedTask"}, *tasks)
+	require.Equal(t, []string{"github.com/goosz/commonz_test.tracedRegion"}, *regions)
+}
+
+func TestStartCallerRegion(t *testing.T) {
+	tasks, regions, restore := commonz.RecordTraceNames()
+	t.Cleanup(restore)
+
+	captureExecutionTrace(t, func() {
+		tracedRegion(context.Background())
+	})
+	require.Empty(t, *tasks)
+	require.Equal(t, []string{"github.com/goosz/commonz_test.tracedRegion"}, *regions)
+}
+
+func TestStartCallerTask_tracingDisabled(t *testing.T) {
+	require.False(t, trace.IsEnabled())
+	ctx, end := commonz.StartCallerTask(context.Background())
+	defer end()
+	defer commonz.StartCallerRegion(ctx)()
+}
+
+func tracedTask(ctx context.Context) {
+	ctx, end := commonz.StartCallerTask(ctx)
+	defer end()
+	tracedRegion(ctx)
+}
+
+func tracedRegion(ctx context.Context) {
+	defer commonz.StartCallerRegion(ctx)()
+}
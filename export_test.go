@@ -1,7 +1,45 @@
 package commonz
 
-import "reflect"
+import (
+	"context"
+	"reflect"
+	"runtime/trace"
+)
 
 func TypeNameWithDepth(t reflect.Type, maxDepth int) string {
 	return typeNameWithDepth(t, maxDepth)
 }
+
+var PackageNameFromPath = packageNameFromPath
+
+// DeprecatedForTest is a deprecated function in package commonz, so that tests in
+// package commonz_test observe themselves as its external caller.
+func DeprecatedForTest() error {
+	return deprecatedForTestInternal()
+}
+
+func deprecatedForTestInternal() error {
+	return Deprecated("use something else")
+}
+
+func ResetDeprecationWarnings() {
+	deprecationWarned.Clear()
+}
+
+// RecordTraceNames makes StartCallerTask and StartCallerRegion record the names they pass
+// to runtime/trace, which they still call, until the returned restore function is called.
+func RecordTraceNames() (tasks, regions *[]string, restore func()) {
+	tasks, regions = new([]string), new([]string)
+	newTraceTask = func(ctx context.Context, name string) (context.Context, *trace.Task) {
+		*tasks = append(*tasks, name)
+		return trace.NewTask(ctx, name)
+	}
+	startTraceRegion = func(ctx context.Context, name string) *trace.Region {
+		*regions = append(*regions, name)
+		return trace.StartRegion(ctx, name)
+	}
+	return tasks, regions, func() {
+		newTraceTask = trace.NewTask
+		startTraceRegion = trace.StartRegion
+	}
+}
@@ -0,0 +1,51 @@
+package commonz
+
+import (
+	"fmt"
+	"reflect"
+	"runtime"
+	"strings"
+)
+
+// methodValueSuffix is the suffix of the name of the wrapper function the compiler
+// generates for method values, e.g. "pkg.(*T).Method-fm".
+const methodValueSuffix = "-fm"
+
+// FuncInfo returns the CallerInfo of a function value, such as a registered handler,
+// along with the source location of the function's definition. The PC of the returned
+// CallerFrame is the entry point of the function.
+//
+// FuncInfo works with top-level functions, method expressions (T.Method), method values
+// (v.Method), closures and instantiated generic functions. CallerInfo is named the same
+// way as by GetCaller, e.g. "(*T).Method" or "Outer.func1". For method values, the
+// compiler generates a wrapper whose source location is not that of the method; the
+// method is named, but File and Line are empty. Use a method expression to obtain the
+// location of a method's definition.
+//
+// Returns an error if fn is nil, is a nil function, or is not a function.
+func FuncInfo(fn any) (CallerFrame, error) {
+	if fn == nil {
+		return CallerFrame{}, fmt.Errorf("FuncInfo: nil argument")
+	}
+	v := reflect.ValueOf(fn)
+	if v.Kind() != reflect.Func {
+		return CallerFrame{}, fmt.Errorf("FuncInfo: argument of type %s is not a function", TypeName(v.Type()))
+	}
+	if v.IsNil() {
+		return CallerFrame{}, fmt.Errorf("FuncInfo: nil function of type %s", TypeName(v.Type()))
+	}
+
+	f := runtime.FuncForPC(v.Pointer())
+	if f == nil {
+		return CallerFrame{CallerInfo: unknownCallerInfo(), PC: v.Pointer()}, nil
+	}
+	name, isMethodValue := strings.CutSuffix(f.Name(), methodValueSuffix)
+	frame := CallerFrame{
+		CallerInfo: ParseCallerInfo(name),
+		PC:         f.Entry(),
+	}
+	if !isMethodValue {
+		frame.File, frame.Line = f.FileLine(f.Entry())
+	}
+	return frame, nil
+}
@@ -0,0 +1,119 @@
+package commonz_test
+
+import (
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/goosz/commonz"
+	"github.com/stretchr/testify/require"
+)
+
+// funcInfoLine returns the line of its caller, to locate function definitions below.
+func funcInfoLine() int {
+	_, _, line, _ := runtime.Caller(1)
+	return line
+}
+
+var funcInfoTopLevelLine = funcInfoLine() + 2
+
+func funcInfoTopLevel() {}
+
+type funcInfoReceiver struct{}
+
+var funcInfoValueMethodLine = funcInfoLine() + 2
+
+func (funcInfoReceiver) Value() {}
+
+var funcInfoPointerMethodLine = funcInfoLine() + 2
+
+func (*funcInfoReceiver) Pointer() {}
+
+var funcInfoGenericLine = funcInfoLine() + 2
+
+func funcInfoGeneric[T any]() {}
+
+func TestFuncInfo(t *testing.T) {
+	closureLine := funcInfoLine() + 1
+	closure := func() {}
+
+	tests := []struct {
+		name     string
+		fn       any
+		function string
+		line     int
+	}{
+		{"top-level function", funcInfoTopLevel, "funcInfoTopLevel", funcInfoTopLevelLine},
+		{"value method expression", funcInfoReceiver.Value, "funcInfoReceiver.Value", funcInfoValueMethodLine},
+		{"pointer method expression", (*funcInfoReceiver).Pointer, "(*funcInfoReceiver).Pointer", funcInfoPointerMethodLine},
+		{"generic function", funcInfoGeneric[int], "funcInfoGeneric[...]", funcInfoGenericLine},
+		{"closure", closure, "TestFuncInfo.func1", closureLine},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			frame, err := commonz.FuncInfo(tt.fn)
+			require.NoError(t, err)
+			require.Equal(t, commonz.CallerInfo{
+				Package:  "github.com/goosz/commonz_test",
+				Function: tt.function,
+			}, frame.CallerInfo)
+			require.Equal(t, "func_info_test.go", filepath.Base(frame.File))
+			require.Equal(t, tt.line, frame.Line)
+			require.NotZero(t, frame.PC)
+		})
+	}
+}
+
+func TestFuncInfo_methodValues(t *testing.T) {
+	receiver := &funcInfoReceiver{}
+	tests := []struct {
+		name     string
+		fn       any
+		function string
+	}{
+		{"value method", receiver.Value, "funcInfoReceiver.Value"},
+		{"pointer method", receiver.Pointer, "(*funcInfoReceiver).Pointer"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			frame, err := commonz.FuncInfo(tt.fn)
+			require.NoError(t, err)
+			require.Equal(t, tt.function, frame.Function)
+			require.Empty(t, frame.File, "the location of method values is not available")
+			require.Zero(t, frame.Line)
+		})
+	}
+}
+
+func TestFuncInfo_standardLibrary(t *testing.T) {
+	frame, err := commonz.FuncInfo(fmt.Println)
+	require.NoError(t, err)
+	require.Equal(t, commonz.CallerInfo{Package: "fmt", Function: "Println"}, frame.CallerInfo)
+	require.True(t, strings.HasSuffix(filepath.ToSlash(frame.File), "fmt/print.go"))
+}
+
+func TestFuncInfo_errors(t *testing.T) {
+	var nilFunc func(int) error
+
+	tests := []struct {
+		name  string
+		fn    any
+		error string
+	}{
+		{"nil", nil, "FuncInfo: nil argument"},
+		{"nil function", nilFunc, "FuncInfo: nil function of type func(int) error"},
+		{"not a function", funcInfoReceiver{}, "FuncInfo: argument of type github.com/goosz/commonz_test.funcInfoReceiver is not a function"},
+		{"int", 42, "FuncInfo: argument of type int is not a function"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := commonz.FuncInfo(tt.fn)
+			require.EqualError(t, err, tt.error)
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package commonz
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// maxFuncTraceArgLen is the length beyond which a formatted argument is truncated
+// in the arguments summary of TraceFunc.
+const maxFuncTraceArgLen = 40
+
+// funcTracer is the output configuration of TraceFunc.
+type funcTracer struct {
+	writer io.Writer    // Set by SetFuncTraceWriter
+	logger *slog.Logger // Set by SetFuncTraceLogger
+
+	mu     sync.Mutex     // Serializes writes to writer and updates to depths
+	depths map[uint64]int // Current nesting depth per goroutine ID
+}
+
+// activeFuncTracer is nil while function tracing is disabled, which is the default.
+var activeFuncTracer atomic.Pointer[funcTracer]
+
+// noopFuncTraceEnd is returned by TraceFunc while tracing is disabled.
+func noopFuncTraceEnd() {}
+
+// SetFuncTraceWriter enables function tracing with TraceFunc, writing one line of
+// text per function entry and exit to w. Passing nil disables function tracing.
+func SetFuncTraceWriter(w io.Writer) {
+	if w == nil {
+		activeFuncTracer.Store(nil)
+		return
+	}
+	activeFuncTracer.Store(&funcTracer{writer: w, depths: make(map[uint64]int)})
+}
+
+// SetFuncTraceLogger enables function tracing with TraceFunc, logging function entry
+// and exit as debug records to logger. Passing nil disables function tracing.
+func SetFuncTraceLogger(logger *slog.Logger) {
+	if logger == nil {
+		activeFuncTracer.Store(nil)
+		return
+	}
+	activeFuncTracer.Store(&funcTracer{logger: logger, depths: make(map[uint64]int)})
+}
+
+// TraceFunc records entry into the function calling it, and returns a function that
+// records its exit along with the elapsed time. It is intended to be used as:
+//
+//	func Handle(id int, name string) {
+//		defer commonz.TraceFunc(id, name)()
+//		...
+//	}
+//
+// The optional args are summarized in the entry record, each formatted with %v and
+// truncated if long. Records are indented by the nesting depth of traced functions
+// on the current goroutine.
+//
+// Output goes to the writer or logger set with SetFuncTraceWriter or SetFuncTraceLogger.
+// While tracing is disabled, which is the default, or the logger drops debug records,
+// TraceFunc only performs an atomic load (and the logger's level check) and does not
+// allocate (although boxing args at the call site may).
+func TraceFunc(args ...any) func() {
+	ft := activeFuncTracer.Load()
+	if ft == nil || ft.logger != nil && !ft.logger.Enabled(context.Background(), slog.LevelDebug) {
+		return noopFuncTraceEnd
+	}
+
+	caller := GetCaller(ParentCaller)
+	gid := goroutineID()
+	summary := summarizeFuncTraceArgs(args)
+
+	ft.mu.Lock()
+	depth := ft.depths[gid]
+	ft.depths[gid] = depth + 1
+	ft.mu.Unlock()
+
+	ft.emit(depth, "enter", caller, fmt.Sprintf("-> %s(%s)", caller, summary), slog.String("args", summary))
+
+	start := time.Now()
+	return func() {
+		elapsed := time.Since(start)
+
+		ft.mu.Lock()
+		if depth == 0 {
+			delete(ft.depths, gid)
+		} else {
+			ft.depths[gid] = depth
+		}
+		ft.mu.Unlock()
+
+		ft.emit(depth, "exit", caller, fmt.Sprintf("<- %s (%s)", caller, elapsed), slog.Duration("elapsed", elapsed))
+	}
+}
+
+// emit writes a single trace record, either as an indented line of text or as a log record.
+func (ft *funcTracer) emit(depth int, msg string, caller CallerInfo, line string, attr slog.Attr) {
+	if ft.logger != nil {
+		ft.logger.LogAttrs(context.Background(), slog.LevelDebug, msg,
+			slog.String("caller", caller.String()),
+			slog.Int("depth", depth),
+			attr)
+		return
+	}
+
+	ft.mu.Lock()
+	defer ft.mu.Unlock()
+	_, _ = io.WriteString(ft.writer, strings.Repeat("  ", depth)+line+"\n")
+}
+
+// summarizeFuncTraceArgs formats args as a comma separated list, truncating long values.
+func summarizeFuncTraceArgs(args []any) string {
+	parts := make([]string, len(args))
+	for i, arg := range args {
+		s := fmt.Sprintf("%v", arg)
+		if r := []rune(s); len(r) > maxFuncTraceArgLen {
+			s = string(r[:maxFuncTraceArgLen]) + "..."
+		}
+		parts[i] = s
+	}
+	return strings.Join(parts, ", ")
+}
+
+// goroutineID returns the ID of the current goroutine, as shown in goroutine dumps.
+// The runtime does not expose it directly, so it is parsed from the stack header
+// "goroutine N [...]". Returns 0 if the header cannot be parsed.
+func goroutineID() uint64 {
+	var buf [64]byte
+	header := buf[:runtime.Stack(buf[:], false)]
+	header = bytes.TrimPrefix(header, []byte("goroutine "))
+	if end := bytes.IndexByte(header, ' '); end >= 0 {
+		header = header[:end]
+	}
+	id, err := strconv.ParseUint(string(header), 10, 64)
+	if err != nil {
+		return 0
+	}
+	return id
+}
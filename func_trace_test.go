@@ -0,0 +1,98 @@
+package commonz_test
+
+import (
+	"bytes"
+	"log/slog"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/goosz/commonz"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTraceFunc_writer(t *testing.T) {
+	var buf bytes.Buffer
+	commonz.SetFuncTraceWriter(&buf)
+	t.Cleanup(func() { commonz.SetFuncTraceWriter(nil) })
+
+	tracedOuter(7, "a string that is much longer than the forty rune limit for arguments")
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	require.Len(t, lines, 4)
+	require.Equal(t, "-> github.com/goosz/commonz_test.tracedOuter(7, a string that is much longer than the fo...)", lines[0])
+	require.Equal(t, "  -> github.com/goosz/commonz_test.tracedInner()", lines[1])
+	require.Regexp(t, regexp.MustCompile(`^  <- github.com/goosz/commonz_test.tracedInner \(\S+\)$`), lines[2])
+	require.Regexp(t, regexp.MustCompile(`^<- github.com/goosz/commonz_test.tracedOuter \(\S+\)$`), lines[3])
+}
+
+func TestTraceFunc_depthIsPerGoroutine(t *testing.T) {
+	var buf bytes.Buffer
+	commonz.SetFuncTraceWriter(&buf)
+	t.Cleanup(func() { commonz.SetFuncTraceWriter(nil) })
+
+	end := commonz.TraceFunc()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		tracedInner()
+	}()
+	<-done
+	end()
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	require.Len(t, lines, 4)
+	require.Equal(t, "-> github.com/goosz/commonz_test.tracedInner()", lines[1], "other goroutines should not be indented")
+}
+
+func TestTraceFunc_logger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+		ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey || a.Key == "elapsed" {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+	commonz.SetFuncTraceLogger(logger)
+	t.Cleanup(func() { commonz.SetFuncTraceLogger(nil) })
+
+	tracedInner()
+
+	require.Equal(t,
+		"level=DEBUG msg=enter caller=github.com/goosz/commonz_test.tracedInner depth=0 args=\"\"\n"+
+			"level=DEBUG msg=exit caller=github.com/goosz/commonz_test.tracedInner depth=0\n",
+		buf.String())
+}
+
+func TestTraceFunc_disabled(t *testing.T) {
+	commonz.SetFuncTraceWriter(nil)
+
+	allocs := testing.AllocsPerRun(100, func() {
+		commonz.TraceFunc()()
+	})
+	require.Zero(t, allocs, "TraceFunc should not allocate while disabled")
+}
+
+func TestTraceFunc_loggerDisabledForDebug(t *testing.T) {
+	var buf bytes.Buffer
+	commonz.SetFuncTraceLogger(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelInfo})))
+	t.Cleanup(func() { commonz.SetFuncTraceLogger(nil) })
+
+	allocs := testing.AllocsPerRun(100, func() {
+		commonz.TraceFunc()()
+	})
+	require.Zero(t, allocs, "TraceFunc should not allocate while the logger drops debug records")
+	require.Empty(t, buf.String())
+}
+
+func tracedOuter(n int, s string) {
+	defer commonz.TraceFunc(n, s)()
+	tracedInner()
+}
+
+func tracedInner() {
+	defer commonz.TraceFunc()()
+}
@@ -0,0 +1,97 @@
+package commonz
+
+import (
+	"cmp"
+	"maps"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// GoroutineInfo describes a goroutine started with Go or GoWithLabels.
+type GoroutineInfo struct {
+	ID      uint64            // Registry-assigned identifier, unique for the life of the process
+	Name    string            // The name given when the goroutine was started
+	Labels  map[string]string // Optional labels given when the goroutine was started
+	Origin  CallerInfo        // The function that started the goroutine
+	Started time.Time         // The time the goroutine was started
+}
+
+// goroutineRegistry holds the live goroutines started through Go and GoWithLabels.
+var goroutineRegistry = struct {
+	sync.Mutex
+	nextID atomic.Uint64
+	live   map[uint64]GoroutineInfo
+}{
+	live: make(map[uint64]GoroutineInfo),
+}
+
+// Go runs fn in a new goroutine and records it in the registry of tracked goroutines
+// until fn returns. The CallerInfo of the function calling Go is recorded as the
+// goroutine's origin, playing the role of the "created by" frame of a goroutine dump.
+//
+// Returns the registry-assigned ID of the new goroutine.
+func Go(name string, fn func()) uint64 {
+	return goTracked(name, nil, fn)
+}
+
+// GoWithLabels is like Go, but also attaches the given labels to the tracked goroutine.
+// The labels map is copied, so later modifications by the caller are not observed.
+func GoWithLabels(name string, labels map[string]string, fn func()) uint64 {
+	return goTracked(name, labels, fn)
+}
+
+// goTracked registers and starts the goroutine. It must be called directly from
+// Go or GoWithLabels so that the origin is found at a fixed depth.
+func goTracked(name string, labels map[string]string, fn func()) uint64 {
+	info := GoroutineInfo{
+		ID:      goroutineRegistry.nextID.Add(1),
+		Name:    name,
+		Labels:  maps.Clone(labels),
+		Origin:  GetCaller(GrandparentCaller), // Skip goTracked and Go/GoWithLabels
+		Started: time.Now(),
+	}
+
+	goroutineRegistry.Lock()
+	goroutineRegistry.live[info.ID] = info
+	goroutineRegistry.Unlock()
+
+	go func() {
+		defer func() {
+			goroutineRegistry.Lock()
+			delete(goroutineRegistry.live, info.ID)
+			goroutineRegistry.Unlock()
+		}()
+		fn()
+	}()
+
+	return info.ID
+}
+
+// TrackedGoroutines returns a snapshot of the live goroutines started with Go or
+// GoWithLabels, ordered by ID (and hence by start order).
+func TrackedGoroutines() []GoroutineInfo {
+	goroutineRegistry.Lock()
+	infos := make([]GoroutineInfo, 0, len(goroutineRegistry.live))
+	for _, info := range goroutineRegistry.live {
+		info.Labels = maps.Clone(info.Labels)
+		infos = append(infos, info)
+	}
+	goroutineRegistry.Unlock()
+
+	slices.SortFunc(infos, func(a, b GoroutineInfo) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+	return infos
+}
+
+// TrackedGoroutine returns the live tracked goroutine with the given ID.
+// The second return value is false if no such goroutine is running.
+func TrackedGoroutine(id uint64) (GoroutineInfo, bool) {
+	goroutineRegistry.Lock()
+	defer goroutineRegistry.Unlock()
+	info, ok := goroutineRegistry.live[id]
+	info.Labels = maps.Clone(info.Labels)
+	return info, ok
+}
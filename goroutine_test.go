@@ -0,0 +1,80 @@
+package commonz_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/goosz/commonz"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGo(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{})
+	before := time.Now()
+
+	id := commonz.Go("worker", func() {
+		close(started)
+		<-release
+	})
+	<-started
+
+	info, ok := commonz.TrackedGoroutine(id)
+	require.True(t, ok, "goroutine should be tracked while running")
+	require.Equal(t, id, info.ID)
+	require.Equal(t, "worker", info.Name)
+	require.Nil(t, info.Labels)
+	require.Equal(t, commonz.CallerInfo{
+		Package:  "github.com/goosz/commonz_test",
+		Function: "TestGo",
+	}, info.Origin, "origin should be the function that called Go")
+	require.False(t, info.Started.Before(before))
+
+	close(release)
+	require.Eventually(t, func() bool {
+		_, ok := commonz.TrackedGoroutine(id)
+		return !ok
+	}, time.Second, time.Millisecond, "goroutine should be removed from the registry when it returns")
+}
+
+func TestGoWithLabels(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+
+	labels := map[string]string{"component": "indexer"}
+	id := commonz.GoWithLabels("labelled", labels, func() { <-release })
+	labels["component"] = "modified"
+
+	info, ok := commonz.TrackedGoroutine(id)
+	require.True(t, ok)
+	require.Equal(t, map[string]string{"component": "indexer"}, info.Labels, "labels should be copied")
+	require.Equal(t, "TestGoWithLabels", info.Origin.Function)
+
+	info.Labels["component"] = "mutated"
+	again, _ := commonz.TrackedGoroutine(id)
+	require.Equal(t, "indexer", again.Labels["component"], "returned labels should not alias the registry")
+}
+
+func TestTrackedGoroutines(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+
+	first := commonz.Go("first", func() { <-release })
+	second := commonz.Go("second", func() { <-release })
+
+	var found []string
+	var lastID uint64
+	for _, info := range commonz.TrackedGoroutines() {
+		require.Greater(t, info.ID, lastID, "goroutines should be ordered by ID")
+		lastID = info.ID
+		if info.ID == first || info.ID == second {
+			found = append(found, info.Name)
+		}
+	}
+	require.Equal(t, []string{"first", "second"}, found)
+}
+
+func TestTrackedGoroutineUnknownID(t *testing.T) {
+	_, ok := commonz.TrackedGoroutine(0)
+	require.False(t, ok)
+}
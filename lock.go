@@ -0,0 +1,514 @@
+package commonz
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"runtime"
+	"slices"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+	"weak"
+)
+
+// Mutex is a drop-in replacement for [sync.Mutex] that records where it is acquired.
+//
+// For every acquisition site (the source location of the call to Lock), the time spent
+// waiting for and holding the lock is aggregated and reported by LockStats, and the
+// current holder is reported by LockHolders. This makes the caller machinery of this
+// package available for diagnosing contention and deadlocks, at the cost of looking up
+// the calling program counter and updating a few counters on every acquisition; use it
+// where that is acceptable.
+//
+// The zero value is an unlocked mutex. A Mutex must not be copied after first use.
+type Mutex struct {
+	Name string // Optional name shown by LockHolders and DumpLocks; defaults to the address
+
+	mu    sync.Mutex
+	state lockState
+}
+
+var _ sync.Locker = (*Mutex)(nil)
+
+// Lock locks m, like [sync.Mutex.Lock].
+func (m *Mutex) Lock() {
+	id := m.state.beginWait(m, false, 1)
+	m.mu.Lock()
+	m.state.acquired(id)
+}
+
+// TryLock tries to lock m, like [sync.Mutex.TryLock].
+func (m *Mutex) TryLock() bool {
+	id := m.state.beginWait(m, false, 1)
+	if !m.mu.TryLock() {
+		m.state.cancel(id)
+		return false
+	}
+	m.state.acquired(id)
+	return true
+}
+
+// Unlock unlocks m, like [sync.Mutex.Unlock].
+func (m *Mutex) Unlock() {
+	m.state.released(false, 0)
+	m.mu.Unlock()
+}
+
+// RWMutex is a drop-in replacement for [sync.RWMutex] that records where it is acquired,
+// in the same way as Mutex. Read and write acquisitions are tracked separately.
+//
+// The zero value is an unlocked mutex. An RWMutex must not be copied after first use.
+type RWMutex struct {
+	Name string // Optional name shown by LockHolders and DumpLocks; defaults to the address
+
+	mu    sync.RWMutex
+	state lockState
+}
+
+var _ sync.Locker = (*RWMutex)(nil)
+
+// Lock locks rw for writing, like [sync.RWMutex.Lock].
+func (rw *RWMutex) Lock() {
+	id := rw.state.beginWait(rw, false, 1)
+	rw.mu.Lock()
+	rw.state.acquired(id)
+}
+
+// Unlock unlocks rw for writing, like [sync.RWMutex.Unlock].
+func (rw *RWMutex) Unlock() {
+	rw.state.released(false, 0)
+	rw.mu.Unlock()
+}
+
+// RLock locks rw for reading, like [sync.RWMutex.RLock].
+func (rw *RWMutex) RLock() {
+	rw.rlock()
+}
+
+// RUnlock undoes a single RLock call, like [sync.RWMutex.RUnlock].
+func (rw *RWMutex) RUnlock() {
+	// Read locks may be released by another goroutine than the one that acquired them,
+	// so prefer a hold of the current goroutine, if known, but fall back to the oldest one.
+	var gid uint64
+	if lockTracking.Load() > 0 {
+		gid = goroutineID()
+	}
+	rw.state.released(true, gid)
+	rw.mu.RUnlock()
+}
+
+// RLocker returns a [sync.Locker] that locks and unlocks rw for reading.
+func (rw *RWMutex) RLocker() sync.Locker {
+	return (*rlocker)(rw)
+}
+
+// rlock must be called directly from the exported read locking methods.
+func (rw *RWMutex) rlock() {
+	id := rw.state.beginWait(rw, true, 2)
+	rw.mu.RLock()
+	rw.state.acquired(id)
+}
+
+type rlocker RWMutex
+
+func (r *rlocker) Lock()   { (*RWMutex)(r).rlock() }
+func (r *rlocker) Unlock() { (*RWMutex)(r).RUnlock() }
+
+// LockSiteStats aggregates the acquisitions of Mutex and RWMutex locks at a single site.
+type LockSiteStats struct {
+	Site         CallerFrame   // The call to Lock, RLock or TryLock
+	Shared       bool          // Whether the site acquires read locks
+	Acquisitions int64         // The number of acquisitions
+	TotalWait    time.Duration // The total time spent waiting to acquire the lock
+	MaxWait      time.Duration // The longest time spent waiting to acquire the lock
+	TotalHold    time.Duration // The total time the lock was held, for released acquisitions
+	MaxHold      time.Duration // The longest time the lock was held, for released acquisitions
+}
+
+// LockHolder describes a Mutex or RWMutex that is currently held.
+type LockHolder struct {
+	Lock      string      // The lock's Name, or its address if it has no name
+	Site      CallerFrame // The call that acquired the lock
+	Goroutine uint64      // The ID of the goroutine that acquired the lock, as shown in goroutine dumps; see Stack
+	Since     time.Time   // The time the lock was acquired
+	Shared    bool        // Whether the lock is held for reading
+
+	// The stack (of up to 64 frames) of the acquiring goroutine at the time of the call to
+	// Lock, starting with Site. Stacks and goroutine IDs are only captured while a
+	// LockWatchdog is running; otherwise Stack is nil and Goroutine is 0.
+	Stack []CallerFrame
+}
+
+// LockWaiter describes a goroutine waiting to acquire a Mutex or RWMutex.
+type LockWaiter struct {
+	Lock      string      // The lock's Name, or its address if it has no name
+	Site      CallerFrame // The call that is waiting to acquire the lock
+	Goroutine uint64      // The ID of the waiting goroutine, as shown in goroutine dumps; see LockHolder.Stack
+	Since     time.Time   // The time the goroutine started waiting
+	Shared    bool        // Whether the goroutine is waiting to acquire the lock for reading
+
+	// The stack of the waiting goroutine, starting with Site, as for LockHolder.
+	Stack []CallerFrame
+}
+
+// lockState is the bookkeeping of a single Mutex or RWMutex: its current acquisitions and
+// the goroutines waiting to acquire it. Its own mutex is only held briefly, and never while
+// waiting for the lock.
+type lockState struct {
+	mu      sync.Mutex
+	lock    any    // The *Mutex or *RWMutex, set when registered on the first acquisition
+	nextID  uint64 // The ID of the last acquisition
+	holds   []lockAcquisition
+	waiters []lockAcquisition
+}
+
+// lockAcquisition is a single acquisition of a lock, from the start of waiting for it until
+// it is released. Its site and stack are only resolved when reported.
+type lockAcquisition struct {
+	id        uint64    // Identifies the acquisition among those of the same lock
+	pc        uintptr   // The program counter of the acquisition site, or 0 if unknown
+	stack     []uintptr // The stack starting with pc, only captured while tracking
+	goroutine uint64    // The ID of the acquiring goroutine, only recorded while tracking
+	shared    bool
+	since     time.Time // The time the lock was acquired, or waiting for it started
+	site      *lockSiteCounters
+}
+
+// lockSiteKey identifies an acquisition site; read and write acquisitions are kept apart.
+type lockSiteKey struct {
+	pc     uintptr
+	shared bool
+}
+
+// lockSiteCounters aggregates the acquisitions at a single site. Durations are in nanoseconds.
+type lockSiteCounters struct {
+	acquisitions atomic.Int64
+	totalWait    atomic.Int64
+	maxWait      atomic.Int64
+	totalHold    atomic.Int64
+	maxHold      atomic.Int64
+}
+
+// lockSites maps each lockSiteKey to its *lockSiteCounters.
+var lockSites sync.Map
+
+// lockRegistry holds weak references to the Mutex and RWMutex locks acquired so far, so
+// that their acquisitions can be enumerated. Locks are registered on their first acquisition.
+var lockRegistry struct {
+	sync.Mutex
+	states  []weak.Pointer[lockState]
+	pruneAt int // The number of states beyond which those of collected locks are pruned
+}
+
+// lockTracking counts the running LockWatchdogs; stacks and goroutine IDs are captured
+// while it is positive.
+var lockTracking atomic.Int32
+
+// lockSymbolizer caches the resolution of acquisition sites.
+var lockSymbolizer Symbolizer
+
+// beginWait records that the current goroutine is about to wait for lock, and returns the
+// ID of the acquisition. The skip parameter is the number of frames between the caller of
+// beginWait and the call to be recorded as the acquisition site.
+func (s *lockState) beginWait(lock any, shared bool, skip int) uint64 {
+	a := lockAcquisition{shared: shared}
+	if lockTracking.Load() > 0 {
+		pcs := make([]uintptr, 64)
+		a.stack = pcs[:runtime.Callers(skip+2, pcs)] // +2 to skip runtime.Callers and beginWait itself
+		if len(a.stack) > 0 {
+			a.pc = a.stack[0]
+		}
+		a.goroutine = goroutineID()
+	} else {
+		var pcs [1]uintptr
+		if runtime.Callers(skip+2, pcs[:]) > 0 {
+			a.pc = pcs[0]
+		}
+	}
+	a.site = lockSiteCountersFor(lockSiteKey{pc: a.pc, shared: shared})
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.lock == nil {
+		s.lock = lock
+		registerLockState(s)
+	}
+	s.nextID++
+	a.id = s.nextID
+	a.since = time.Now()
+	s.waiters = append(s.waiters, a)
+	return a.id
+}
+
+// cancel records that the goroutine stopped waiting without acquiring the lock.
+func (s *lockState) cancel(id uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.waiters = slices.DeleteFunc(s.waiters, func(a lockAcquisition) bool { return a.id == id })
+}
+
+// acquired records that the lock was acquired after waiting.
+func (s *lockState) acquired(id uint64) {
+	s.mu.Lock()
+	i := slices.IndexFunc(s.waiters, func(a lockAcquisition) bool { return a.id == id })
+	a := s.waiters[i]
+	s.waiters = slices.Delete(s.waiters, i, i+1)
+	now := time.Now()
+	wait := now.Sub(a.since)
+	a.since = now
+	s.holds = append(s.holds, a)
+	s.mu.Unlock()
+
+	a.site.acquisitions.Add(1)
+	a.site.totalWait.Add(int64(wait))
+	storeMaxInt64(&a.site.maxWait, int64(wait))
+}
+
+// released records the release of the oldest acquisition in the given mode, preferring
+// one by goroutine gid if it is not 0. It does nothing if there is no such acquisition.
+func (s *lockState) released(shared bool, gid uint64) {
+	s.mu.Lock()
+	i := -1
+	for j, a := range s.holds {
+		if a.shared != shared {
+			continue
+		}
+		if i < 0 {
+			i = j
+		}
+		if gid != 0 && a.goroutine == gid {
+			i = j
+			break
+		}
+	}
+	if i < 0 {
+		s.mu.Unlock()
+		return
+	}
+	a := s.holds[i]
+	s.holds = slices.Delete(s.holds, i, i+1)
+	s.mu.Unlock()
+
+	held := time.Since(a.since)
+	a.site.totalHold.Add(int64(held))
+	storeMaxInt64(&a.site.maxHold, int64(held))
+}
+
+// lockSiteCountersFor returns the counters of the acquisition site identified by key,
+// which are created on first use.
+func lockSiteCountersFor(key lockSiteKey) *lockSiteCounters {
+	if counters, ok := lockSites.Load(key); ok {
+		return counters.(*lockSiteCounters)
+	}
+	counters, _ := lockSites.LoadOrStore(key, new(lockSiteCounters))
+	return counters.(*lockSiteCounters)
+}
+
+// storeMaxInt64 stores x in v if it is larger than the current value.
+func storeMaxInt64(v *atomic.Int64, x int64) {
+	for old := v.Load(); x > old && !v.CompareAndSwap(old, x); old = v.Load() {
+	}
+}
+
+// registerLockState adds s to lockRegistry, pruning the states of collected locks whenever
+// their number has doubled since the last pruning.
+func registerLockState(s *lockState) {
+	lockRegistry.Lock()
+	defer lockRegistry.Unlock()
+	if len(lockRegistry.states) >= lockRegistry.pruneAt {
+		pruneLockStates()
+		lockRegistry.pruneAt = max(2*len(lockRegistry.states), 64)
+	}
+	lockRegistry.states = append(lockRegistry.states, weak.Make(s))
+}
+
+// pruneLockStates removes the states of collected locks from lockRegistry, which must be locked.
+func pruneLockStates() {
+	lockRegistry.states = slices.DeleteFunc(lockRegistry.states, func(p weak.Pointer[lockState]) bool {
+		return p.Value() == nil
+	})
+}
+
+// lockSnapshot is a copy of the acquisitions of a single lock and of its waiters.
+type lockSnapshot struct {
+	state   *lockState
+	holds   []lockAcquisition
+	waiters []lockAcquisition
+}
+
+// snapshotLocks returns a snapshot of every registered lock that is held or awaited.
+func snapshotLocks() []lockSnapshot {
+	lockRegistry.Lock()
+	pruneLockStates()
+	states := make([]*lockState, 0, len(lockRegistry.states))
+	for _, p := range lockRegistry.states {
+		if s := p.Value(); s != nil {
+			states = append(states, s)
+		}
+	}
+	lockRegistry.Unlock()
+
+	var snapshots []lockSnapshot
+	for _, s := range states {
+		s.mu.Lock()
+		if len(s.holds) > 0 || len(s.waiters) > 0 {
+			snapshots = append(snapshots, lockSnapshot{
+				state:   s,
+				holds:   slices.Clone(s.holds),
+				waiters: slices.Clone(s.waiters),
+			})
+		}
+		s.mu.Unlock()
+	}
+	return snapshots
+}
+
+// describe resolves an acquisition of the snapshot's lock into a LockHolder. For waiters,
+// the result is converted to a LockWaiter.
+func (snap lockSnapshot) describe(a lockAcquisition) LockHolder {
+	holder := LockHolder{
+		Lock:      lockName(snap.state.lock),
+		Goroutine: a.goroutine,
+		Since:     a.since,
+		Shared:    a.shared,
+	}
+	if a.pc != 0 {
+		holder.Site = lockSymbolizer.lookup(a.pc)
+	}
+	if len(a.stack) > 0 {
+		holder.Stack = lockSymbolizer.Symbolize(a.stack)
+	}
+	return holder
+}
+
+// lockName returns the Name of lock, a *Mutex or *RWMutex, or its address if it has none.
+func lockName(lock any) string {
+	var name string
+	switch lock := lock.(type) {
+	case *Mutex:
+		name = lock.Name
+	case *RWMutex:
+		name = lock.Name
+	}
+	if name == "" {
+		name = fmt.Sprintf("%p", lock)
+	}
+	return name
+}
+
+// LockStats returns the statistics of all acquisition sites of Mutex and RWMutex locks
+// since the start of the process or the last ResetLockStats, ordered by descending
+// total wait time.
+func LockStats() []LockSiteStats {
+	var stats []LockSiteStats
+	lockSites.Range(func(key, value any) bool {
+		k, counters := key.(lockSiteKey), value.(*lockSiteCounters)
+		s := LockSiteStats{
+			Shared:       k.shared,
+			Acquisitions: counters.acquisitions.Load(),
+			TotalWait:    time.Duration(counters.totalWait.Load()),
+			MaxWait:      time.Duration(counters.maxWait.Load()),
+			TotalHold:    time.Duration(counters.totalHold.Load()),
+			MaxHold:      time.Duration(counters.maxHold.Load()),
+		}
+		if k.pc != 0 {
+			s.Site = lockSymbolizer.lookup(k.pc)
+		}
+		if s.Acquisitions > 0 { // Sites of pending first acquisitions are left out
+			stats = append(stats, s)
+		}
+		return true
+	})
+
+	slices.SortFunc(stats, func(a, b LockSiteStats) int {
+		return cmp.Or(
+			cmp.Compare(b.TotalWait, a.TotalWait),
+			cmp.Compare(a.Site.File, b.Site.File),
+			cmp.Compare(a.Site.Line, b.Site.Line))
+	})
+	return stats
+}
+
+// ResetLockStats discards the statistics reported by LockStats.
+func ResetLockStats() {
+	lockSites.Clear()
+}
+
+// LockHolders returns the Mutex and RWMutex locks that are currently held, longest held first.
+func LockHolders() []LockHolder {
+	var holders []LockHolder
+	for _, snap := range snapshotLocks() {
+		for _, a := range snap.holds {
+			holders = append(holders, snap.describe(a))
+		}
+	}
+
+	slices.SortFunc(holders, func(a, b LockHolder) int {
+		return a.Since.Compare(b.Since)
+	})
+	return holders
+}
+
+// LockWaiters returns the goroutines currently waiting to acquire a Mutex or RWMutex,
+// longest waiting first.
+func LockWaiters() []LockWaiter {
+	var waiters []LockWaiter
+	for _, snap := range snapshotLocks() {
+		for _, a := range snap.waiters {
+			waiters = append(waiters, LockWaiter(snap.describe(a)))
+		}
+	}
+
+	sortLockWaiters(waiters)
+	return waiters
+}
+
+// sortLockWaiters orders waiters by the time they started waiting.
+func sortLockWaiters(waiters []LockWaiter) {
+	slices.SortFunc(waiters, func(a, b LockWaiter) int {
+		return a.Since.Compare(b.Since)
+	})
+}
+
+// DumpLocks writes a human-readable listing of the currently held locks to w, one per line,
+// followed by the goroutines waiting for locks. Goroutine IDs are included if they were
+// captured by a running LockWatchdog:
+//
+//	cache held by goroutine 7 for 1.5s, acquired at github.com/user/app.(*Cache).Get (/src/app/cache.go:42)
+//	cache awaited by goroutine 9 for 1.2s, at github.com/user/app.(*Cache).Put (/src/app/cache.go:57)
+func DumpLocks(w io.Writer) error {
+	now := time.Now()
+	for _, holder := range LockHolders() {
+		mode := "held"
+		if holder.Shared {
+			mode = "read-held"
+		}
+		_, err := fmt.Fprintf(w, "%s %s%s for %s, acquired at %s\n",
+			holder.Lock, mode, byLockGoroutine(holder.Goroutine), now.Sub(holder.Since), holder.Site)
+		if err != nil {
+			return err
+		}
+	}
+	for _, waiter := range LockWaiters() {
+		mode := "awaited"
+		if waiter.Shared {
+			mode = "read-awaited"
+		}
+		_, err := fmt.Fprintf(w, "%s %s%s for %s, at %s\n",
+			waiter.Lock, mode, byLockGoroutine(waiter.Goroutine), now.Sub(waiter.Since), waiter.Site)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// byLockGoroutine renders the goroutine of a lock acquisition for DumpLocks, if known.
+func byLockGoroutine(gid uint64) string {
+	if gid == 0 {
+		return ""
+	}
+	return " by goroutine " + strconv.FormatUint(gid, 10)
+}
@@ -0,0 +1,37 @@
+package commonz_test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/goosz/commonz"
+)
+
+// BenchmarkMutex benchmarks uncontended Lock and Unlock of a Mutex
+func BenchmarkMutex(b *testing.B) {
+	var m commonz.Mutex
+	for i := 0; i < b.N; i++ {
+		m.Lock()
+		m.Unlock()
+	}
+}
+
+// BenchmarkMutex_syncMutex benchmarks uncontended Lock and Unlock of a sync.Mutex for comparison
+func BenchmarkMutex_syncMutex(b *testing.B) {
+	var m sync.Mutex
+	for i := 0; i < b.N; i++ {
+		m.Lock()
+		m.Unlock()
+	}
+}
+
+// BenchmarkMutex_parallel benchmarks Lock and Unlock of distinct Mutexes from parallel goroutines
+func BenchmarkMutex_parallel(b *testing.B) {
+	b.RunParallel(func(pb *testing.PB) {
+		var m commonz.Mutex
+		for pb.Next() {
+			m.Lock()
+			m.Unlock()
+		}
+	})
+}
@@ -0,0 +1,171 @@
+package commonz_test
+
+import (
+	"bytes"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/goosz/commonz"
+	"github.com/stretchr/testify/require"
+)
+
+// lockStatsFor returns the statistics of the sites in the given function.
+func lockStatsFor(function string) []commonz.LockSiteStats {
+	var stats []commonz.LockSiteStats
+	for _, s := range commonz.LockStats() {
+		if s.Site.Function == function {
+			stats = append(stats, s)
+		}
+	}
+	return stats
+}
+
+func TestMutex(t *testing.T) {
+	commonz.ResetLockStats()
+	m := &commonz.Mutex{Name: "test-mutex"}
+
+	m.Lock()
+	holders := commonz.LockHolders()
+	require.Len(t, holders, 1)
+	require.Equal(t, "test-mutex", holders[0].Lock)
+	require.Equal(t, "TestMutex", holders[0].Site.Function)
+	require.False(t, holders[0].Shared)
+	require.Zero(t, holders[0].Goroutine, "goroutine IDs should only be captured while a watchdog is running")
+
+	require.False(t, m.TryLock())
+	time.Sleep(5 * time.Millisecond)
+	m.Unlock()
+	require.Empty(t, commonz.LockHolders())
+
+	require.True(t, m.TryLock())
+	m.Unlock()
+
+	stats := lockStatsFor("TestMutex")
+	require.Len(t, stats, 2, "Lock and TryLock are separate sites")
+	for _, s := range stats {
+		require.Equal(t, int64(1), s.Acquisitions)
+		require.False(t, s.Shared)
+	}
+	lockSite := stats[0]
+	if lockSite.Site.Line > stats[1].Site.Line {
+		lockSite = stats[1]
+	}
+	require.GreaterOrEqual(t, lockSite.TotalHold, 5*time.Millisecond)
+	require.Equal(t, lockSite.TotalHold, lockSite.MaxHold)
+}
+
+func TestMutex_contention(t *testing.T) {
+	commonz.ResetLockStats()
+	var m commonz.Mutex
+
+	m.Lock()
+	started, acquired := make(chan struct{}), make(chan struct{})
+	go func() {
+		close(started)
+		contendedLock(&m)
+		close(acquired)
+	}()
+	<-started
+	time.Sleep(20 * time.Millisecond)
+	m.Unlock()
+	<-acquired
+
+	stats := lockStatsFor("contendedLock")
+	require.Len(t, stats, 1)
+	require.Equal(t, int64(1), stats[0].Acquisitions)
+	require.GreaterOrEqual(t, stats[0].TotalWait, 10*time.Millisecond, "waiting should have started before the sleep")
+	require.Equal(t, stats[0].TotalWait, stats[0].MaxWait)
+	require.Equal(t, "contendedLock", commonz.LockStats()[0].Site.Function, "sites should be ordered by wait time")
+}
+
+func TestRWMutex(t *testing.T) {
+	commonz.ResetLockStats()
+	rw := &commonz.RWMutex{Name: "test-rwmutex"}
+
+	rw.RLock()
+	rw.RLocker().Lock()
+	holders := commonz.LockHolders()
+	require.Len(t, holders, 2)
+	for _, h := range holders {
+		require.True(t, h.Shared)
+		require.Equal(t, "TestRWMutex", h.Site.Function)
+	}
+	rw.RUnlock()
+	rw.RLocker().Unlock()
+	require.Empty(t, commonz.LockHolders())
+
+	rw.Lock()
+	holders = commonz.LockHolders()
+	require.Len(t, holders, 1)
+	require.False(t, holders[0].Shared)
+	rw.Unlock()
+
+	stats := lockStatsFor("TestRWMutex")
+	require.Len(t, stats, 3)
+	var shared int
+	for _, s := range stats {
+		if s.Shared {
+			shared++
+		}
+	}
+	require.Equal(t, 2, shared)
+}
+
+func TestRWMutex_concurrentReaders(t *testing.T) {
+	var rw commonz.RWMutex
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 100 {
+				rw.RLock()
+				rw.RUnlock()
+				rw.Lock()
+				rw.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	require.Empty(t, commonz.LockHolders())
+}
+
+func TestDumpLocks(t *testing.T) {
+	var named commonz.Mutex
+	named.Name = "cache"
+	var unnamed commonz.RWMutex
+
+	named.Lock()
+	defer named.Unlock()
+	unnamed.RLock()
+	defer unnamed.RUnlock()
+
+	var buf bytes.Buffer
+	require.NoError(t, commonz.DumpLocks(&buf))
+	lines := regexp.MustCompile(`(?m)^.+$`).FindAllString(buf.String(), -1)
+	require.Len(t, lines, 2)
+	require.Regexp(t, `^cache held for \S+, acquired at github.com/goosz/commonz_test.TestDumpLocks \(.*lock_test.go:\d+\)$`, lines[0])
+	require.Regexp(t, `^0x[0-9a-f]+ read-held for \S+, acquired at github.com/goosz/commonz_test.TestDumpLocks \(.*lock_test.go:\d+\)$`, lines[1])
+
+	named.Name = "renamed"
+	require.Equal(t, "renamed", commonz.LockHolders()[0].Lock, "names should be resolved when reported")
+}
+
+func TestMutex_noAllocations(t *testing.T) {
+	var m commonz.Mutex
+	m.Lock()
+	m.Unlock()
+
+	allocs := testing.AllocsPerRun(100, func() {
+		m.Lock()
+		m.Unlock()
+	})
+	require.Zero(t, allocs)
+}
+
+func contendedLock(m *commonz.Mutex) {
+	m.Lock()
+	defer m.Unlock()
+}
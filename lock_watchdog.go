@@ -0,0 +1,151 @@
+package commonz
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+// LockReport describes a Mutex or RWMutex acquisition held longer than a LockWatchdog's threshold.
+type LockReport struct {
+	Holder  LockHolder    // The acquisition that has held the lock too long
+	HeldFor time.Duration // How long the lock had been held when the report was made
+	Waiters []LockWaiter  // The goroutines waiting for the same lock, longest waiting first
+}
+
+// lockAcquisitionKey identifies a lock acquisition reported by a LockWatchdog.
+type lockAcquisitionKey struct {
+	state *lockState
+	id    uint64
+}
+
+// LockWatchdogConfig configures a LockWatchdog.
+type LockWatchdogConfig struct {
+	// Threshold is how long a lock may be held before it is reported.
+	Threshold time.Duration
+
+	// OnStuck is called once for each acquisition held longer than Threshold.
+	OnStuck func(LockReport)
+
+	// OnReleased, if not nil, is called with the original report when an acquisition
+	// previously passed to OnStuck is found to have been released.
+	OnReleased func(LockReport)
+}
+
+// LockWatchdog detects Mutex and RWMutex locks held longer than a threshold, and reports
+// their holders and waiters, including the stacks captured when they called Lock.
+//
+// While any LockWatchdog is running (from NewLockWatchdog until Stop), every lock
+// acquisition captures the stack and goroutine ID of the acquiring goroutine, which is
+// considerably more expensive than the site lookup done otherwise.
+type LockWatchdog struct {
+	config   LockWatchdogConfig
+	mu       sync.Mutex
+	reported map[lockAcquisitionKey]LockReport // Guarded by mu
+	stopOnce sync.Once
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// NewLockWatchdog returns a LockWatchdog with the given configuration, and enables stack
+// and goroutine ID capture for lock acquisitions until Stop is called. Checks are made by calling Check
+// directly or periodically after calling Start.
+func NewLockWatchdog(config LockWatchdogConfig) *LockWatchdog {
+	lockTracking.Add(1)
+	return &LockWatchdog{
+		config:   config,
+		reported: make(map[lockAcquisitionKey]LockReport),
+		stop:     make(chan struct{}),
+	}
+}
+
+// Start runs Check every interval in a new goroutine until Stop is called.
+// It must be called at most once.
+func (w *LockWatchdog) Start(interval time.Duration) {
+	w.done = make(chan struct{})
+	go func() {
+		defer close(w.done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				w.Check()
+			case <-w.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the periodic checks started by Start, waiting for a running check to
+// complete, and disables the capture enabled by NewLockWatchdog. It is safe to
+// call Stop more than once.
+func (w *LockWatchdog) Stop() {
+	w.stopOnce.Do(func() {
+		close(w.stop)
+		if w.done != nil {
+			<-w.done
+		}
+		lockTracking.Add(-1)
+	})
+}
+
+// Check reports the lock acquisitions that have been held longer than the threshold and
+// were not reported by an earlier check, calling OnStuck for each of them, and calls
+// OnReleased for reported acquisitions that have since been released.
+//
+// Returns the new reports, ordered by the time the locks were acquired.
+func (w *LockWatchdog) Check() []LockReport {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	now := time.Now()
+	var stuck, released []LockReport
+
+	held := make(map[lockAcquisitionKey]bool)
+	for _, snap := range snapshotLocks() {
+		for _, hold := range snap.holds {
+			key := lockAcquisitionKey{state: snap.state, id: hold.id}
+			held[key] = true
+			heldFor := now.Sub(hold.since)
+			if _, ok := w.reported[key]; ok || heldFor < w.config.Threshold {
+				continue
+			}
+			report := LockReport{Holder: snap.describe(hold), HeldFor: heldFor}
+			for _, waiter := range snap.waiters {
+				report.Waiters = append(report.Waiters, LockWaiter(snap.describe(waiter)))
+			}
+			sortLockWaiters(report.Waiters)
+			w.reported[key] = report
+			stuck = append(stuck, report)
+		}
+	}
+	for key, report := range w.reported {
+		if !held[key] {
+			delete(w.reported, key)
+			released = append(released, report)
+		}
+	}
+
+	sortLockReports(stuck)
+	sortLockReports(released)
+	if w.config.OnReleased != nil {
+		for _, report := range released {
+			w.config.OnReleased(report)
+		}
+	}
+	if w.config.OnStuck != nil {
+		for _, report := range stuck {
+			w.config.OnStuck(report)
+		}
+	}
+	return stuck
+}
+
+// sortLockReports orders reports by the time the locks were acquired.
+func sortLockReports(reports []LockReport) {
+	slices.SortFunc(reports, func(a, b LockReport) int {
+		return a.Holder.Since.Compare(b.Holder.Since)
+	})
+}
@@ -0,0 +1,145 @@
+package commonz_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/goosz/commonz"
+	"github.com/stretchr/testify/require"
+)
+
+// waitForLockWaiters waits until the given number of goroutines wait for a lock with the given name.
+func waitForLockWaiters(t *testing.T, name string, n int) {
+	t.Helper()
+	require.Eventually(t, func() bool {
+		var count int
+		for _, w := range commonz.LockWaiters() {
+			if w.Lock == name {
+				count++
+			}
+		}
+		return count == n
+	}, time.Second, time.Millisecond)
+}
+
+func TestLockWatchdog(t *testing.T) {
+	var stuck, released []commonz.LockReport
+	watchdog := commonz.NewLockWatchdog(commonz.LockWatchdogConfig{
+		Threshold:  0,
+		OnStuck:    func(r commonz.LockReport) { stuck = append(stuck, r) },
+		OnReleased: func(r commonz.LockReport) { released = append(released, r) },
+	})
+	defer watchdog.Stop()
+
+	m := &commonz.Mutex{Name: "watched"}
+	watchdogHolder(m)
+
+	// Simulate a deadlock: a second goroutine blocks on the held lock
+	acquired := make(chan struct{})
+	go func() {
+		watchdogWaiter(m)
+		close(acquired)
+	}()
+	waitForLockWaiters(t, "watched", 1)
+
+	reports := watchdog.Check()
+	require.Equal(t, reports, stuck)
+	require.Len(t, reports, 1)
+	report := reports[0]
+	require.Equal(t, "watched", report.Holder.Lock)
+	require.Equal(t, "watchdogHolder", report.Holder.Site.Function)
+	require.Equal(t, report.Holder.Site, report.Holder.Stack[0])
+	require.Equal(t, "TestLockWatchdog", report.Holder.Stack[1].Function, "the holder's stack should be captured at Lock time")
+
+	require.Len(t, report.Waiters, 1)
+	waiter := report.Waiters[0]
+	require.Equal(t, "watched", waiter.Lock)
+	require.Equal(t, "watchdogWaiter", waiter.Site.Function)
+	require.Equal(t, "watchdogWaiter", waiter.Stack[0].Function)
+	require.Equal(t, "TestLockWatchdog.func3", waiter.Stack[1].Function)
+	require.NotZero(t, report.Holder.Goroutine)
+	require.NotZero(t, waiter.Goroutine)
+	require.NotEqual(t, report.Holder.Goroutine, waiter.Goroutine)
+
+	require.Empty(t, watchdog.Check(), "acquisitions should only be reported once")
+	require.Empty(t, released)
+
+	var dump strings.Builder
+	require.NoError(t, commonz.DumpLocks(&dump))
+	require.Regexp(t, `(?m)^watched awaited by goroutine \d+ for \S+, at github.com/goosz/commonz_test.watchdogWaiter `, dump.String())
+
+	m.Unlock()
+	<-acquired
+	require.Empty(t, watchdog.Check())
+	require.Equal(t, stuck, released, "released acquisitions should be reported with their original report")
+}
+
+func TestLockWatchdog_threshold(t *testing.T) {
+	watchdog := commonz.NewLockWatchdog(commonz.LockWatchdogConfig{Threshold: time.Hour})
+	defer watchdog.Stop()
+
+	var m commonz.Mutex
+	m.Lock()
+	defer m.Unlock()
+	require.Empty(t, watchdog.Check(), "locks held shorter than the threshold should not be reported")
+}
+
+func TestLockWatchdog_rwMutex(t *testing.T) {
+	watchdog := commonz.NewLockWatchdog(commonz.LockWatchdogConfig{})
+	defer watchdog.Stop()
+
+	rw := &commonz.RWMutex{Name: "watched-rw"}
+	rw.RLock()
+	acquired := make(chan struct{})
+	go func() {
+		rw.Lock()
+		rw.Unlock()
+		close(acquired)
+	}()
+	waitForLockWaiters(t, "watched-rw", 1)
+
+	reports := watchdog.Check()
+	require.Len(t, reports, 1)
+	require.True(t, reports[0].Holder.Shared)
+	require.Len(t, reports[0].Waiters, 1)
+	require.False(t, reports[0].Waiters[0].Shared)
+
+	rw.RUnlock()
+	<-acquired
+}
+
+func TestLockWatchdog_start(t *testing.T) {
+	reported := make(chan commonz.LockReport, 1)
+	watchdog := commonz.NewLockWatchdog(commonz.LockWatchdogConfig{
+		OnStuck: func(r commonz.LockReport) { reported <- r },
+	})
+
+	m := &commonz.Mutex{Name: "periodic"}
+	m.Lock()
+	watchdog.Start(time.Millisecond)
+	report := <-reported
+	watchdog.Stop()
+	watchdog.Stop()
+	m.Unlock()
+
+	require.Equal(t, "periodic", report.Holder.Lock)
+}
+
+func TestLockHolder_noStackWithoutWatchdog(t *testing.T) {
+	var m commonz.Mutex
+	m.Lock()
+	defer m.Unlock()
+	for _, h := range commonz.LockHolders() {
+		require.Nil(t, h.Stack)
+	}
+}
+
+func watchdogHolder(m *commonz.Mutex) {
+	m.Lock()
+}
+
+func watchdogWaiter(m *commonz.Mutex) {
+	m.Lock()
+	defer m.Unlock()
+}
@@ -0,0 +1,42 @@
+package commonz
+
+import (
+	"context"
+	"runtime/pprof"
+)
+
+// Profiler label keys set by DoWithCallerLabels
+const (
+	PackageLabel  = "package"  // The package of the function that called DoWithCallerLabels
+	FunctionLabel = "function" // The function that called DoWithCallerLabels
+)
+
+// DoWithCallerLabels calls fn with a copy of the parent context carrying runtime/pprof
+// labels derived from the CallerInfo of the function calling DoWithCallerLabels,
+// so that CPU profiles can be sliced by logical entry point.
+//
+// The caller's package and function are set under PackageLabel and FunctionLabel.
+// Additional labels are given as key-value pairs, as for [pprof.Labels], and take
+// precedence over the caller labels if they use the same keys. Like [pprof.Labels],
+// it panics if given an odd number of strings.
+//
+// The labels are applied to the current goroutine for the duration of fn, and are
+// inherited by goroutines started from within fn.
+func DoWithCallerLabels(ctx context.Context, fn func(context.Context), labels ...string) {
+	caller := GetCaller(ParentCaller)
+	args := make([]string, 0, len(labels)+4)
+	args = append(args, PackageLabel, caller.Package, FunctionLabel, caller.Function)
+	args = append(args, labels...)
+	pprof.Do(ctx, pprof.Labels(args...), fn)
+}
+
+// ProfileLabels returns the runtime/pprof labels carried by ctx, such as those set
+// by DoWithCallerLabels. It returns an empty map if ctx carries no labels.
+func ProfileLabels(ctx context.Context) map[string]string {
+	labels := make(map[string]string)
+	pprof.ForLabels(ctx, func(key, value string) bool {
+		labels[key] = value
+		return true
+	})
+	return labels
+}
@@ -0,0 +1,60 @@
+package commonz_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/goosz/commonz"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDoWithCallerLabels(t *testing.T) {
+	var got map[string]string
+	commonz.DoWithCallerLabels(context.Background(), func(ctx context.Context) {
+		got = commonz.ProfileLabels(ctx)
+	}, "tenant", "acme")
+
+	require.Equal(t, map[string]string{
+		commonz.PackageLabel:  "github.com/goosz/commonz_test",
+		commonz.FunctionLabel: "TestDoWithCallerLabels",
+		"tenant":              "acme",
+	}, got)
+}
+
+func TestDoWithCallerLabels_overrideCallerLabels(t *testing.T) {
+	var got map[string]string
+	commonz.DoWithCallerLabels(context.Background(), func(ctx context.Context) {
+		got = commonz.ProfileLabels(ctx)
+	}, commonz.FunctionLabel, "custom")
+
+	require.Equal(t, "custom", got[commonz.FunctionLabel])
+	require.Equal(t, "github.com/goosz/commonz_test", got[commonz.PackageLabel])
+}
+
+func TestDoWithCallerLabels_nested(t *testing.T) {
+	var outer, inner map[string]string
+	commonz.DoWithCallerLabels(context.Background(), func(ctx context.Context) {
+		outer = commonz.ProfileLabels(ctx)
+		nestedEntryPoint(ctx, &inner)
+	}, "request", "42")
+
+	require.Equal(t, "TestDoWithCallerLabels_nested", outer[commonz.FunctionLabel])
+	require.Equal(t, "nestedEntryPoint", inner[commonz.FunctionLabel])
+	require.Equal(t, "42", inner["request"], "labels from the parent context should be inherited")
+}
+
+func TestDoWithCallerLabels_oddLabels(t *testing.T) {
+	require.Panics(t, func() {
+		commonz.DoWithCallerLabels(context.Background(), func(context.Context) {}, "dangling")
+	})
+}
+
+func TestProfileLabels_noLabels(t *testing.T) {
+	require.Empty(t, commonz.ProfileLabels(context.Background()))
+}
+
+func nestedEntryPoint(ctx context.Context, labels *map[string]string) {
+	commonz.DoWithCallerLabels(ctx, func(ctx context.Context) {
+		*labels = commonz.ProfileLabels(ctx)
+	})
+}
@@ -0,0 +1,105 @@
+package commonz
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// SourceLine is a single line of a SourceSnippet.
+type SourceLine struct {
+	Number int    // The 1-based line number
+	Text   string // The text of the line, without the trailing newline
+	Target bool   // Whether this is the line the snippet was requested for
+}
+
+// SourceSnippet is a range of lines from a source file surrounding a target line.
+type SourceSnippet struct {
+	File  string       // The source file
+	Lines []SourceLine // The lines of the snippet, in order
+}
+
+// String renders the snippet with right-aligned line numbers, marking the target line with ">":
+//
+//	  41 | func Handle() {
+//	> 42 | 	process()
+//	  43 | }
+func (s SourceSnippet) String() string {
+	if len(s.Lines) == 0 {
+		return ""
+	}
+	width := len(strconv.Itoa(s.Lines[len(s.Lines)-1].Number))
+	var b strings.Builder
+	for _, line := range s.Lines {
+		marker := " "
+		if line.Target {
+			marker = ">"
+		}
+		fmt.Fprintf(&b, "%s %*d | %s\n", marker, width, line.Number, line.Text)
+	}
+	return b.String()
+}
+
+// sourceFiles caches the lines of source files read by GetSourceSnippet, by file name.
+// Files that do not exist are cached with their error, since in deployed binaries the
+// sources are typically absent and would otherwise be looked up on every call. Other
+// errors, which may be temporary, are not cached.
+var sourceFiles sync.Map // map[string]sourceFile
+
+type sourceFile struct {
+	lines []string
+	err   error
+}
+
+// GetSourceSnippet returns the target line of frame's source location together with up
+// to surrounding lines before and after it, reading the source file on first use and caching
+// its contents for later calls.
+//
+// Source files are usually not available where a binary is deployed. In that case, or
+// if the frame's line is out of range, an error is returned; when the file does not exist
+// the error satisfies errors.Is(err, fs.ErrNotExist).
+func GetSourceSnippet(frame CallerFrame, surrounding int) (SourceSnippet, error) {
+	if frame.File == "" {
+		return SourceSnippet{}, fmt.Errorf("no source file for %s", frame.CallerInfo)
+	}
+	lines, err := readSourceFile(frame.File)
+	if err != nil {
+		return SourceSnippet{}, err
+	}
+	if frame.Line < 1 || frame.Line > len(lines) {
+		return SourceSnippet{}, fmt.Errorf("line %d out of range for %s (%d lines)", frame.Line, frame.File, len(lines))
+	}
+
+	first := max(frame.Line-max(surrounding, 0), 1)
+	last := min(frame.Line+max(surrounding, 0), len(lines))
+	snippet := SourceSnippet{File: frame.File, Lines: make([]SourceLine, 0, last-first+1)}
+	for n := first; n <= last; n++ {
+		snippet.Lines = append(snippet.Lines, SourceLine{
+			Number: n,
+			Text:   lines[n-1],
+			Target: n == frame.Line,
+		})
+	}
+	return snippet, nil
+}
+
+// readSourceFile returns the lines of a source file, using the cache.
+func readSourceFile(file string) ([]string, error) {
+	if cached, ok := sourceFiles.Load(file); ok {
+		return cached.(sourceFile).lines, cached.(sourceFile).err
+	}
+	data, err := os.ReadFile(file)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	sf := sourceFile{err: err}
+	if err == nil {
+		sf.lines = strings.Split(strings.TrimSuffix(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n"), "\n")
+	}
+	cached, _ := sourceFiles.LoadOrStore(file, sf)
+	return cached.(sourceFile).lines, cached.(sourceFile).err
+}
@@ -0,0 +1,124 @@
+package commonz_test
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/goosz/commonz"
+	"github.com/stretchr/testify/require"
+)
+
+// writeSourceFile writes a numbered source file with the given number of lines.
+func writeSourceFile(t *testing.T, lines int) string {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), "source.go")
+	var content strings.Builder
+	for i := 1; i <= lines; i++ {
+		fmt.Fprintf(&content, "line %d\n", i)
+	}
+	require.NoError(t, os.WriteFile(file, []byte(content.String()), 0o600))
+	return file
+}
+
+func TestGetSourceSnippet(t *testing.T) {
+	file := writeSourceFile(t, 9)
+
+	snippet, err := commonz.GetSourceSnippet(commonz.CallerFrame{File: file, Line: 5}, 2)
+	require.NoError(t, err)
+	require.Equal(t, commonz.SourceSnippet{
+		File: file,
+		Lines: []commonz.SourceLine{
+			{Number: 3, Text: "line 3"},
+			{Number: 4, Text: "line 4"},
+			{Number: 5, Text: "line 5", Target: true},
+			{Number: 6, Text: "line 6"},
+			{Number: 7, Text: "line 7"},
+		},
+	}, snippet)
+}
+
+func TestGetSourceSnippet_clamped(t *testing.T) {
+	file := writeSourceFile(t, 3)
+
+	snippet, err := commonz.GetSourceSnippet(commonz.CallerFrame{File: file, Line: 1}, 5)
+	require.NoError(t, err)
+	require.Len(t, snippet.Lines, 3)
+	require.True(t, snippet.Lines[0].Target)
+
+	snippet, err = commonz.GetSourceSnippet(commonz.CallerFrame{File: file, Line: 3}, -1)
+	require.NoError(t, err)
+	require.Equal(t, []commonz.SourceLine{{Number: 3, Text: "line 3", Target: true}}, snippet.Lines)
+}
+
+func TestGetSourceSnippet_cached(t *testing.T) {
+	file := writeSourceFile(t, 3)
+	_, err := commonz.GetSourceSnippet(commonz.CallerFrame{File: file, Line: 1}, 0)
+	require.NoError(t, err)
+
+	require.NoError(t, os.Remove(file))
+	snippet, err := commonz.GetSourceSnippet(commonz.CallerFrame{File: file, Line: 2}, 0)
+	require.NoError(t, err, "source should be served from the cache")
+	require.Equal(t, "line 2", snippet.Lines[0].Text)
+}
+
+func TestGetSourceSnippet_errorCaching(t *testing.T) {
+	dir := t.TempDir()
+
+	missing := filepath.Join(dir, "missing.go")
+	_, err := commonz.GetSourceSnippet(commonz.CallerFrame{File: missing, Line: 1}, 0)
+	require.ErrorIs(t, err, fs.ErrNotExist)
+	require.NoError(t, os.WriteFile(missing, []byte("line 1\n"), 0o600))
+	_, err = commonz.GetSourceSnippet(commonz.CallerFrame{File: missing, Line: 1}, 0)
+	require.ErrorIs(t, err, fs.ErrNotExist, "missing files should be cached")
+
+	// Reading a directory fails with an error other than fs.ErrNotExist.
+	unreadable := filepath.Join(dir, "unreadable.go")
+	require.NoError(t, os.Mkdir(unreadable, 0o700))
+	_, err = commonz.GetSourceSnippet(commonz.CallerFrame{File: unreadable, Line: 1}, 0)
+	require.Error(t, err)
+	require.NotErrorIs(t, err, fs.ErrNotExist)
+	require.NoError(t, os.Remove(unreadable))
+	require.NoError(t, os.WriteFile(unreadable, []byte("line 1\n"), 0o600))
+	snippet, err := commonz.GetSourceSnippet(commonz.CallerFrame{File: unreadable, Line: 1}, 0)
+	require.NoError(t, err, "other errors should not be cached")
+	require.Equal(t, "line 1", snippet.Lines[0].Text)
+}
+
+func TestGetSourceSnippet_errors(t *testing.T) {
+	file := writeSourceFile(t, 3)
+
+	_, err := commonz.GetSourceSnippet(commonz.CallerFrame{File: filepath.Join(t.TempDir(), "missing.go"), Line: 1}, 1)
+	require.ErrorIs(t, err, fs.ErrNotExist)
+
+	_, err = commonz.GetSourceSnippet(commonz.CallerFrame{File: file, Line: 4}, 1)
+	require.ErrorContains(t, err, "line 4 out of range")
+
+	_, err = commonz.GetSourceSnippet(commonz.CallerFrame{File: file, Line: 0}, 1)
+	require.Error(t, err)
+
+	_, err = commonz.GetSourceSnippet(commonz.CallerFrame{}, 1)
+	require.ErrorContains(t, err, "no source file")
+}
+
+func TestGetSourceSnippet_callSite(t *testing.T) {
+	frame := commonz.GetCallerFrameOutside(commonz.CurrentCaller)
+	snippet, err := commonz.GetSourceSnippet(frame, 0)
+	require.NoError(t, err)
+	require.Equal(t, "\tframe := commonz.GetCallerFrameOutside(commonz.CurrentCaller)", snippet.Lines[0].Text)
+}
+
+func TestSourceSnippet_String(t *testing.T) {
+	snippet := commonz.SourceSnippet{
+		Lines: []commonz.SourceLine{
+			{Number: 9, Text: "func Handle() {"},
+			{Number: 10, Text: "\tprocess()", Target: true},
+			{Number: 11, Text: "}"},
+		},
+	}
+	require.Equal(t, "   9 | func Handle() {\n> 10 | \tprocess()\n  11 | }\n", snippet.String())
+	require.Empty(t, commonz.SourceSnippet{}.String())
+}
@@ -0,0 +1,240 @@
+package commonz
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// SpanData is the immutable record of a finished span, as delivered to a SpanExporter.
+type SpanData struct {
+	TraceID    uint64         // The SpanID of the root span of the trace
+	SpanID     uint64         // Tracer-assigned identifier, unique per Tracer
+	ParentID   uint64         // The SpanID of the parent span, or 0 for a root span
+	Name       string         // The span name, defaulting to the String() of Caller
+	Caller     CallerInfo     // The function that started the span
+	Start      time.Time      // The time the span was started
+	End        time.Time      // The time the span was ended
+	Attributes map[string]any // Attributes set on the span
+}
+
+// Duration returns the elapsed time between the start and end of the span.
+func (sd SpanData) Duration() time.Duration {
+	return sd.End.Sub(sd.Start)
+}
+
+// SpanExporter receives spans from a Tracer as they end.
+//
+// This is the extension point for plugging in other tracing systems: an adapter
+// implementing SpanExporter can forward SpanData to e.g. an OpenTelemetry pipeline
+// without this package depending on it. ExportSpan may be called concurrently.
+type SpanExporter interface {
+	ExportSpan(SpanData)
+}
+
+// Tracer creates spans and delivers them to its exporters when they end.
+// A Tracer is safe for concurrent use.
+type Tracer struct {
+	exporters []SpanExporter
+	nextID    atomic.Uint64
+}
+
+// NewTracer returns a Tracer that delivers ended spans to the given exporters, in order.
+func NewTracer(exporters ...SpanExporter) *Tracer {
+	return &Tracer{exporters: slices.Clone(exporters)}
+}
+
+// Start starts a span named after the CallerInfo of the function calling Start.
+// See StartNamed for details.
+func (t *Tracer) Start(ctx context.Context) (context.Context, *Span) {
+	caller := GetCaller(ParentCaller)
+	return t.start(ctx, caller.String(), caller)
+}
+
+// StartNamed starts a span with the given name. If ctx carries a span (see SpanFromContext),
+// the new span is its child; otherwise the new span is the root of a new trace.
+//
+// Returns a context carrying the new span, and the span itself, which must be ended
+// with End for it to be exported.
+func (t *Tracer) StartNamed(ctx context.Context, name string) (context.Context, *Span) {
+	return t.start(ctx, name, GetCaller(ParentCaller))
+}
+
+func (t *Tracer) start(ctx context.Context, name string, caller CallerInfo) (context.Context, *Span) {
+	span := &Span{
+		tracer: t,
+		data: SpanData{
+			SpanID: t.nextID.Add(1),
+			Name:   name,
+			Caller: caller,
+			Start:  time.Now(),
+		},
+	}
+	if parent := SpanFromContext(ctx); parent != nil {
+		span.data.TraceID = parent.data.TraceID
+		span.data.ParentID = parent.data.SpanID
+	} else {
+		span.data.TraceID = span.data.SpanID
+	}
+	return context.WithValue(ctx, spanContextKey{}, span), span
+}
+
+// spanContextKey is the context key under which the current span is stored.
+type spanContextKey struct{}
+
+// SpanFromContext returns the span carried by ctx, or nil if there is none.
+func SpanFromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanContextKey{}).(*Span)
+	return span
+}
+
+// Span is an in-progress span created by a Tracer. A Span is safe for concurrent use.
+type Span struct {
+	tracer *Tracer
+	mu     sync.Mutex
+	data   SpanData
+	ended  bool
+}
+
+// TraceID returns the ID of the trace the span belongs to.
+func (s *Span) TraceID() uint64 {
+	return s.data.TraceID
+}
+
+// SpanID returns the ID of the span.
+func (s *Span) SpanID() uint64 {
+	return s.data.SpanID
+}
+
+// SetAttribute sets an attribute on the span. It has no effect once the span has ended.
+func (s *Span) SetAttribute(key string, value any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ended {
+		return
+	}
+	if s.data.Attributes == nil {
+		s.data.Attributes = make(map[string]any)
+	}
+	s.data.Attributes[key] = value
+}
+
+// End ends the span and delivers it to the Tracer's exporters.
+// Only the first call to End has any effect.
+func (s *Span) End() {
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.data.End = time.Now()
+	data := s.data
+	s.mu.Unlock()
+
+	for _, exporter := range s.tracer.exporters {
+		exporter.ExportSpan(data)
+	}
+}
+
+// InMemorySpanExporter is a SpanExporter that keeps ended spans in memory,
+// which is mostly useful for tests. The zero value is ready to use.
+type InMemorySpanExporter struct {
+	mu    sync.Mutex
+	spans []SpanData
+}
+
+// ExportSpan implements SpanExporter.
+func (e *InMemorySpanExporter) ExportSpan(span SpanData) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.spans = append(e.spans, span)
+}
+
+// Spans returns the exported spans in the order in which they ended.
+func (e *InMemorySpanExporter) Spans() []SpanData {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return slices.Clone(e.spans)
+}
+
+// Reset discards all exported spans.
+func (e *InMemorySpanExporter) Reset() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.spans = nil
+}
+
+// ChromeTraceExporter is a SpanExporter that renders ended spans in the Chrome
+// trace-event JSON format, which can be loaded into chrome://tracing or Perfetto
+// for local viewing. Each trace is shown as its own thread. The zero value is ready to use.
+//
+// The arguments of each event are the span's attributes, along with its caller, ID and
+// parent ID under the keys "commonz.caller", "commonz.span_id" and "commonz.parent_id".
+// Attribute values that cannot be marshalled to JSON are rendered with fmt.Sprint.
+type ChromeTraceExporter struct {
+	InMemorySpanExporter
+}
+
+// chromeTraceEvent is a complete ("X") event of the Chrome trace-event format.
+type chromeTraceEvent struct {
+	Name      string         `json:"name"`
+	Category  string         `json:"cat"`
+	Phase     string         `json:"ph"`
+	Timestamp int64          `json:"ts"`  // Microseconds
+	Duration  int64          `json:"dur"` // Microseconds
+	ProcessID int            `json:"pid"`
+	ThreadID  uint64         `json:"tid"`
+	Args      map[string]any `json:"args,omitempty"`
+}
+
+// chromeTraceArg returns the JSON encoding of an attribute value, or of its fmt.Sprint
+// rendering if it cannot be encoded.
+func chromeTraceArg(value any) json.RawMessage {
+	b, err := json.Marshal(value)
+	if err != nil {
+		b, _ = json.Marshal(fmt.Sprint(value))
+	}
+	return b
+}
+
+// WriteTo writes the exported spans to w as a Chrome trace-event JSON document.
+func (e *ChromeTraceExporter) WriteTo(w io.Writer) (int64, error) {
+	spans := e.Spans()
+	events := make([]chromeTraceEvent, len(spans))
+	for i, span := range spans {
+		args := make(map[string]any, len(span.Attributes)+3)
+		for key, value := range span.Attributes {
+			args[key] = chromeTraceArg(value)
+		}
+		args["commonz.caller"] = span.Caller.String()
+		args["commonz.span_id"] = span.SpanID
+		if span.ParentID != 0 {
+			args["commonz.parent_id"] = span.ParentID
+		}
+		events[i] = chromeTraceEvent{
+			Name:      span.Name,
+			Category:  span.Caller.Package,
+			Phase:     "X",
+			Timestamp: span.Start.UnixMicro(),
+			Duration:  span.Duration().Microseconds(),
+			ProcessID: 1,
+			ThreadID:  span.TraceID,
+			Args:      args,
+		}
+	}
+
+	b, err := json.Marshal(struct {
+		TraceEvents []chromeTraceEvent `json:"traceEvents"`
+	}{events})
+	if err != nil {
+		return 0, err
+	}
+	n, err := w.Write(b)
+	return int64(n), err
+}
@@ -0,0 +1,135 @@
+package commonz_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/goosz/commonz"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTracer_Start(t *testing.T) {
+	exporter := &commonz.InMemorySpanExporter{}
+	tracer := commonz.NewTracer(exporter)
+
+	ctx, span := tracer.Start(context.Background())
+	require.Same(t, span, commonz.SpanFromContext(ctx))
+	span.SetAttribute("user", "alice")
+	span.End()
+
+	spans := exporter.Spans()
+	require.Len(t, spans, 1)
+	require.Equal(t, "github.com/goosz/commonz_test.TestTracer_Start", spans[0].Name)
+	require.Equal(t, commonz.CallerInfo{
+		Package:  "github.com/goosz/commonz_test",
+		Function: "TestTracer_Start",
+	}, spans[0].Caller)
+	require.Equal(t, spans[0].SpanID, spans[0].TraceID, "root span should start a new trace")
+	require.Zero(t, spans[0].ParentID)
+	require.Equal(t, map[string]any{"user": "alice"}, spans[0].Attributes)
+	require.False(t, spans[0].End.Before(spans[0].Start))
+}
+
+func TestTracer_parentChild(t *testing.T) {
+	exporter := &commonz.InMemorySpanExporter{}
+	tracer := commonz.NewTracer(exporter)
+
+	ctx, root := tracer.StartNamed(context.Background(), "request")
+	tracedChild(ctx, tracer)
+	root.End()
+
+	spans := exporter.Spans()
+	require.Len(t, spans, 2)
+	child, parent := spans[0], spans[1]
+	require.Equal(t, "request", parent.Name)
+	require.Equal(t, "TestTracer_parentChild", parent.Caller.Function)
+	require.Equal(t, "github.com/goosz/commonz_test.tracedChild", child.Name)
+	require.Equal(t, parent.SpanID, child.ParentID)
+	require.Equal(t, parent.TraceID, child.TraceID)
+	require.Equal(t, root.TraceID(), child.TraceID)
+}
+
+func TestSpan_End(t *testing.T) {
+	exporter := &commonz.InMemorySpanExporter{}
+	tracer := commonz.NewTracer(exporter)
+
+	_, span := tracer.StartNamed(context.Background(), "once")
+	span.End()
+	span.SetAttribute("ignored", true)
+	span.End()
+
+	spans := exporter.Spans()
+	require.Len(t, spans, 1, "End should only export the span once")
+	require.Nil(t, spans[0].Attributes, "attributes set after End should be ignored")
+}
+
+func TestTracer_multipleExporters(t *testing.T) {
+	first, second := &commonz.InMemorySpanExporter{}, &commonz.InMemorySpanExporter{}
+	tracer := commonz.NewTracer(first, second)
+
+	_, span := tracer.StartNamed(context.Background(), "fanout")
+	span.End()
+
+	require.Len(t, first.Spans(), 1)
+	require.Len(t, second.Spans(), 1)
+
+	first.Reset()
+	require.Empty(t, first.Spans())
+	require.Len(t, second.Spans(), 1)
+}
+
+func TestSpanFromContext_noSpan(t *testing.T) {
+	require.Nil(t, commonz.SpanFromContext(context.Background()))
+}
+
+func TestChromeTraceExporter(t *testing.T) {
+	exporter := &commonz.ChromeTraceExporter{}
+	tracer := commonz.NewTracer(exporter)
+
+	ctx, root := tracer.StartNamed(context.Background(), "request")
+	root.SetAttribute("path", "/users")
+	root.SetAttribute("caller", "user attribute")
+	root.SetAttribute("callback", func() {})
+	root.SetAttribute("ratio", math.Inf(1))
+	tracedChild(ctx, tracer)
+	root.End()
+
+	var buf bytes.Buffer
+	n, err := exporter.WriteTo(&buf)
+	require.NoError(t, err)
+	require.Equal(t, int64(buf.Len()), n)
+
+	var doc struct {
+		TraceEvents []struct {
+			Name     string         `json:"name"`
+			Category string         `json:"cat"`
+			Phase    string         `json:"ph"`
+			ThreadID uint64         `json:"tid"`
+			Args     map[string]any `json:"args"`
+		} `json:"traceEvents"`
+	}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &doc))
+	require.Len(t, doc.TraceEvents, 2)
+
+	child, parent := doc.TraceEvents[0], doc.TraceEvents[1]
+	require.Equal(t, "github.com/goosz/commonz_test.tracedChild", child.Name)
+	require.Equal(t, "github.com/goosz/commonz_test", child.Category)
+	require.Equal(t, "X", child.Phase)
+	require.Equal(t, parent.ThreadID, child.ThreadID, "spans of one trace should share a thread")
+	require.Equal(t, parent.Args["commonz.span_id"], child.Args["commonz.parent_id"])
+	require.Equal(t, "request", parent.Name)
+	require.Equal(t, "/users", parent.Args["path"])
+	require.Equal(t, "user attribute", parent.Args["caller"], "user attributes should not be overwritten")
+	require.Equal(t, "github.com/goosz/commonz_test.TestChromeTraceExporter", parent.Args["commonz.caller"])
+	require.NotContains(t, parent.Args, "commonz.parent_id")
+	require.Regexp(t, `^0x[0-9a-f]+$`, parent.Args["callback"], "values that cannot be marshalled should be printed")
+	require.Equal(t, "+Inf", parent.Args["ratio"])
+}
+
+func tracedChild(ctx context.Context, tracer *commonz.Tracer) {
+	_, span := tracer.Start(ctx)
+	defer span.End()
+}
@@ -0,0 +1,171 @@
+package commonz
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ANSI escape sequences used by StackFormatter
+const (
+	ansiReset = "\x1b[0m"
+	ansiBold  = "\x1b[1m"
+	ansiDim   = "\x1b[2m"
+	ansiCyan  = "\x1b[36m"
+)
+
+// GetStack returns the call stack as CallerFrames, innermost frame first, starting at
+// the specified depth. The depth parameter has the same meaning as for GetCaller, so
+// GetStack(CurrentCaller) starts with the function that called GetStack.
+// Returns nil if depth is negative or exceeds the depth of the stack.
+func GetStack(depth int) []CallerFrame {
+	if depth < 0 {
+		return nil
+	}
+	pcs := callers(depth + 1) // +1 to skip GetStack itself
+	if len(pcs) == 0 {
+		return nil
+	}
+	return SymbolizePCs(pcs)
+}
+
+// StackFormatter renders CallerFrames as a human-friendly stack listing. Consecutive
+// frames of the same package are grouped under a package header, frames of the standard
+// library (including the runtime) are dimmed, and source paths are shortened.
+//
+// The zero value renders without colors, with paths relative to the root of the main module.
+type StackFormatter struct {
+	Color      bool   // Whether to emit ANSI color and style sequences
+	ModuleRoot string // Directory that source paths are shown relative to; defaults to the main module's root
+}
+
+// defaultModuleRoot returns the root directory of the main module: the nearest directory
+// holding a go.mod file, searching upwards from the working directory at the time of the
+// first call. Returns "" if there is none, as is usual for deployed binaries.
+var defaultModuleRoot = sync.OnceValue(func() string {
+	dir, err := os.Getwd()
+	if err != nil {
+		return ""
+	}
+	for {
+		if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+})
+
+// NewStackFormatter returns a StackFormatter that emits colors if w is a terminal,
+// unless the NO_COLOR environment variable is set or TERM is "dumb".
+func NewStackFormatter(w io.Writer) StackFormatter {
+	return StackFormatter{Color: isColorTerminal(w)}
+}
+
+// isColorTerminal reports whether w is a terminal that should receive ANSI colors.
+func isColorTerminal(w io.Writer) bool {
+	if _, noColor := os.LookupEnv("NO_COLOR"); noColor || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// Format renders frames, innermost first:
+//
+//	github.com/user/app
+//	    (*Server).Handle  server.go:42
+//	    main  main.go:12
+//	runtime
+//	    main  $GOROOT/src/runtime/proc.go:283
+func (sf StackFormatter) Format(frames []CallerFrame) string {
+	root := sf.ModuleRoot
+	if root == "" {
+		root = defaultModuleRoot()
+	}
+
+	var b strings.Builder
+	for i, frame := range frames {
+		std := isStdlibPackage(frame.Package)
+		if i == 0 || frames[i-1].Package != frame.Package {
+			sf.write(&b, frame.Package, ansiBold+ansiCyan, std)
+			b.WriteByte('\n')
+		}
+		b.WriteString("    ")
+		sf.write(&b, frame.Function, ansiBold, std)
+		b.WriteString("  ")
+		location := shortenSourcePath(frame.File, root, std)
+		if frame.Line > 0 {
+			location += ":" + strconv.Itoa(frame.Line)
+		}
+		if frame.Inlined {
+			location += " (inlined)"
+		}
+		sf.write(&b, location, ansiDim, std)
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// Fprint writes the formatted frames to w.
+func (sf StackFormatter) Fprint(w io.Writer, frames []CallerFrame) error {
+	_, err := io.WriteString(w, sf.Format(frames))
+	return err
+}
+
+// write appends s to b, styled when colors are enabled. Standard library
+// frames are dimmed rather than styled.
+func (sf StackFormatter) write(b *strings.Builder, s, style string, std bool) {
+	if !sf.Color {
+		b.WriteString(s)
+		return
+	}
+	if std {
+		style = ansiDim
+	}
+	b.WriteString(style)
+	b.WriteString(s)
+	b.WriteString(ansiReset)
+}
+
+// isStdlibPackage reports whether pkg is a standard library package, i.e. whether the
+// first element of its import path lacks a dot, as module paths must have one.
+func isStdlibPackage(pkg string) bool {
+	if pkg == "" || strings.HasPrefix(pkg, "<") {
+		return false
+	}
+	first, _, _ := strings.Cut(pkg, "/")
+	return !strings.Contains(first, ".") && pkg != "main"
+}
+
+// shortenSourcePath shortens file for display: relative to root if it is inside root,
+// relative to GOROOT for standard library files, relative to the module cache if it is
+// inside it, and unchanged otherwise.
+func shortenSourcePath(file, root string, std bool) string {
+	if file == "" {
+		return "?"
+	}
+	if root != "" {
+		if rel, err := filepath.Rel(root, file); err == nil && !strings.HasPrefix(rel, "..") {
+			return filepath.ToSlash(rel)
+		}
+	}
+	slashed := filepath.ToSlash(file)
+	if i := strings.LastIndex(slashed, "/src/"); std && i >= 0 {
+		return "$GOROOT" + slashed[i:]
+	}
+	if _, after, ok := strings.Cut(slashed, "/pkg/mod/"); ok {
+		return after
+	}
+	return file
+}
@@ -0,0 +1,110 @@
+package commonz_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/goosz/commonz"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetStack(t *testing.T) {
+	stack := stackHelper(commonz.CurrentCaller)
+	require.GreaterOrEqual(t, len(stack), 3)
+	require.Equal(t, "stackHelper", stack[0].Function)
+	require.Equal(t, "TestGetStack", stack[1].Function)
+	require.Equal(t, "tRunner", stack[2].Function)
+	require.Equal(t, "stack_test.go", filepath.Base(stack[0].File))
+
+	parent := stackHelper(commonz.ParentCaller)
+	require.Equal(t, "TestGetStack", parent[0].Function)
+	require.Equal(t, stack[2:], parent[1:])
+
+	require.Nil(t, commonz.GetStack(-1))
+	require.Nil(t, commonz.GetStack(1000))
+}
+
+func TestStackFormatter_Format(t *testing.T) {
+	root := filepath.FromSlash("/src/app")
+	frames := []commonz.CallerFrame{
+		{
+			CallerInfo: commonz.CallerInfo{Package: "github.com/user/app", Function: "(*Server).handle"},
+			File:       filepath.FromSlash("/src/app/server.go"),
+			Line:       42,
+			Inlined:    true,
+		},
+		{
+			CallerInfo: commonz.CallerInfo{Package: "github.com/user/app", Function: "(*Server).Serve"},
+			File:       filepath.FromSlash("/src/app/server.go"),
+			Line:       30,
+		},
+		{
+			CallerInfo: commonz.CallerInfo{Package: "github.com/other/lib", Function: "Run"},
+			File:       "/home/user/go/pkg/mod/github.com/other/lib@v1.2.3/run.go",
+			Line:       7,
+		},
+		{
+			CallerInfo: commonz.CallerInfo{Package: "net/http", Function: "(*conn).serve"},
+			File:       "/usr/local/go/src/net/http/server.go",
+			Line:       2092,
+		},
+		{
+			CallerInfo: commonz.CallerInfo{Package: "<unknown-package>", Function: "<unknown-function>"},
+		},
+	}
+
+	formatter := commonz.StackFormatter{ModuleRoot: root}
+	require.Equal(t, strings.Join([]string{
+		"github.com/user/app",
+		"    (*Server).handle  server.go:42 (inlined)",
+		"    (*Server).Serve  server.go:30",
+		"github.com/other/lib",
+		"    Run  github.com/other/lib@v1.2.3/run.go:7",
+		"net/http",
+		"    (*conn).serve  $GOROOT/src/net/http/server.go:2092",
+		"<unknown-package>",
+		"    <unknown-function>  ?",
+		"",
+	}, "\n"), formatter.Format(frames))
+
+	formatter.Color = true
+	colored := formatter.Format(frames[2:4])
+	require.Equal(t, strings.Join([]string{
+		"\x1b[1m\x1b[36mgithub.com/other/lib\x1b[0m",
+		"    \x1b[1mRun\x1b[0m  \x1b[2mgithub.com/other/lib@v1.2.3/run.go:7\x1b[0m",
+		"\x1b[2mnet/http\x1b[0m",
+		"    \x1b[2m(*conn).serve\x1b[0m  \x1b[2m$GOROOT/src/net/http/server.go:2092\x1b[0m",
+		"",
+	}, "\n"), colored, "standard library frames should be dimmed")
+}
+
+func TestStackFormatter_Fprint(t *testing.T) {
+	var buf bytes.Buffer
+	formatter := commonz.NewStackFormatter(&buf)
+	require.False(t, formatter.Color, "colors should be disabled for non-terminals")
+
+	require.NoError(t, formatter.Fprint(&buf, commonz.GetStack(commonz.CurrentCaller)))
+	require.True(t, strings.HasPrefix(buf.String(),
+		"github.com/goosz/commonz_test\n    TestStackFormatter_Fprint  stack_test.go:"), buf.String())
+	require.Contains(t, buf.String(), "\ntesting\n    tRunner  $GOROOT/src/testing/testing.go:")
+}
+
+func TestStackFormatter_moduleRoot(t *testing.T) {
+	frames := commonz.GetStack(commonz.CurrentCaller)
+	t.Chdir("assertz")
+	require.True(t, strings.HasPrefix(commonz.StackFormatter{}.Format(frames),
+		"github.com/goosz/commonz_test\n    TestStackFormatter_moduleRoot  stack_test.go:"),
+		"paths should be relative to the module root rather than the working directory")
+}
+
+func TestNewStackFormatter_noColor(t *testing.T) {
+	t.Setenv("NO_COLOR", "1")
+	require.False(t, commonz.NewStackFormatter(os.Stdout).Color)
+}
+
+func stackHelper(depth int) []commonz.CallerFrame {
+	return commonz.GetStack(depth)
+}
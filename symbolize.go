@@ -0,0 +1,170 @@
+package commonz
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+)
+
+// CallerFrame is a CallerInfo together with the source location and program counter
+// of a single stack frame.
+type CallerFrame struct {
+	CallerInfo
+	File    string  // The source file, or "" if unknown
+	Line    int     // The source line, or 0 if unknown
+	PC      uintptr // The program counter the frame was resolved from
+	Inlined bool    // Whether the function was inlined into the next (calling) frame
+}
+
+func (cf CallerFrame) String() string {
+	return fmt.Sprintf("%s (%s:%d)", cf.CallerInfo, cf.File, cf.Line)
+}
+
+// SymbolizePCs resolves program counters, as returned by [runtime.Callers], into
+// CallerFrames with source file and line, innermost frame first.
+//
+// [runtime.Callers] already reports a separate program counter for each inlined call,
+// so inlined functions get their own frame, marked as Inlined, and each program counter
+// yields one frame. A raw program counter from another source, such as a stack trace,
+// that lies within inlined code is expanded into a frame for each inlined function and
+// one for the enclosing real function, all carrying that program counter. Program
+// counters that cannot be resolved yield a frame for which IsUnknown() is true.
+func SymbolizePCs(pcs []uintptr) []CallerFrame {
+	expanded := make([]pcFrames, len(pcs))
+	for i, pc := range pcs {
+		expanded[i] = symbolizePC(pc)
+	}
+	return flattenPCFrames(pcs, expanded)
+}
+
+// GetCallerFrameOutside is like GetCallerOutside, but returns a CallerFrame that also
+// carries the source location of the call. If no such caller is found, returns a
+// CallerFrame for which IsUnknown() is true.
+func GetCallerFrameOutside(depth int, packages ...string) CallerFrame {
+	if depth < 0 {
+		return CallerFrame{CallerInfo: unknownCallerInfo()}
+	}
+	frame, ok := callerFrameOutside(depth+1, packages) // +1 to skip GetCallerFrameOutside itself
+	if !ok {
+		return CallerFrame{CallerInfo: unknownCallerInfo()}
+	}
+	return newCallerFrame(frame, frame.PC)
+}
+
+// pcFrames holds the logical frames a single program counter resolves to, innermost
+// first and ending with the real function containing it.
+type pcFrames struct {
+	frames []CallerFrame
+	// callerPCs holds, for each frame after the first, the program counter by which
+	// runtime.Callers reports that frame.
+	callerPCs []uintptr
+}
+
+// symbolizePC resolves a single program counter into all of its logical frames.
+func symbolizePC(pc uintptr) pcFrames {
+	// The runtime only expands the frames enclosing an inlined program counter if it is
+	// followed by another one, which the 0 sentinel provides; it resolves to no frame.
+	frames := runtime.CallersFrames([]uintptr{pc, 0})
+	var result pcFrames
+	for {
+		frame, more := frames.Next()
+		if len(result.frames) > 0 {
+			result.callerPCs = append(result.callerPCs, frame.PC+1) // Undo the runtime's adjustment to the call instruction
+		}
+		result.frames = append(result.frames, newCallerFrame(frame, pc))
+		if !more || frame.Func != nil {
+			return result
+		}
+	}
+}
+
+// flattenPCFrames concatenates the frames expanded from pcs. Like [runtime.CallersFrames],
+// it omits the frames enclosing an inlined call when the next program counter reports them,
+// as is the case for the output of runtime.Callers.
+func flattenPCFrames(pcs []uintptr, expanded []pcFrames) []CallerFrame {
+	frames := make([]CallerFrame, 0, len(pcs))
+	for i, e := range expanded {
+		frames = append(frames, e.frames[0])
+		for j, callerPC := range e.callerPCs {
+			if i+1 < len(pcs) && pcs[i+1] == callerPC {
+				break
+			}
+			frames = append(frames, e.frames[j+1])
+		}
+	}
+	return frames
+}
+
+// newCallerFrame converts a frame reported by the runtime into a CallerFrame.
+func newCallerFrame(frame runtime.Frame, pc uintptr) CallerFrame {
+	info := unknownCallerInfo()
+	if frame.Function != "" {
+		info = ParseCallerInfo(frame.Function)
+	}
+	return CallerFrame{
+		CallerInfo: info,
+		File:       frame.File,
+		Line:       frame.Line,
+		PC:         pc,
+		// Func is nil for inlined frames, while Entry still refers to the enclosing real function
+		Inlined: frame.Func == nil && frame.Entry != 0,
+	}
+}
+
+// Symbolizer resolves program counters into CallerFrames like SymbolizePCs, caching the
+// result for each program counter. This makes it suited to tooling that repeatedly
+// resolves stacks sharing many program counters, such as sampling profilers.
+// A Symbolizer is safe for concurrent use; the zero value is ready to use.
+type Symbolizer struct {
+	mu    sync.RWMutex
+	cache map[uintptr]pcFrames
+}
+
+// Symbolize is like SymbolizePCs, but uses and populates the Symbolizer's cache.
+func (s *Symbolizer) Symbolize(pcs []uintptr) []CallerFrame {
+	expanded := make([]pcFrames, len(pcs))
+	for i, pc := range pcs {
+		expanded[i] = s.expand(pc)
+	}
+	return flattenPCFrames(pcs, expanded)
+}
+
+// SymbolizeBatch resolves several stacks at once, returning one slice of frames per stack.
+func (s *Symbolizer) SymbolizeBatch(stacks [][]uintptr) [][]CallerFrame {
+	result := make([][]CallerFrame, len(stacks))
+	for i, pcs := range stacks {
+		result[i] = s.Symbolize(pcs)
+	}
+	return result
+}
+
+// Len returns the number of program counters in the Symbolizer's cache.
+func (s *Symbolizer) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.cache)
+}
+
+// lookup returns the innermost frame for pc, as cached by expand.
+func (s *Symbolizer) lookup(pc uintptr) CallerFrame {
+	return s.expand(pc).frames[0]
+}
+
+// expand returns the frames for pc from the cache, resolving and caching them on a miss.
+func (s *Symbolizer) expand(pc uintptr) pcFrames {
+	s.mu.RLock()
+	expanded, ok := s.cache[pc]
+	s.mu.RUnlock()
+	if ok {
+		return expanded
+	}
+
+	expanded = symbolizePC(pc)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cache == nil {
+		s.cache = make(map[uintptr]pcFrames)
+	}
+	s.cache[pc] = expanded
+	return expanded
+}
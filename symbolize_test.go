@@ -0,0 +1,125 @@
+package commonz_test
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/goosz/commonz"
+	"github.com/stretchr/testify/require"
+)
+
+// callersHere returns the program counters of its caller's stack, starting with the caller.
+//
+//go:noinline
+func callersHere() []uintptr {
+	pcs := make([]uintptr, 32)
+	return pcs[:runtime.Callers(2, pcs)]
+}
+
+// inlinableCallers is small enough to be inlined into its caller.
+func inlinableCallers() []uintptr {
+	return callersHere()
+}
+
+//go:noinline
+func callInlinableCallers() []uintptr {
+	return inlinableCallers()
+}
+
+func TestSymbolizePCs(t *testing.T) {
+	_, file, line, _ := runtime.Caller(0)
+	pcs := callersHere()
+
+	frames := commonz.SymbolizePCs(pcs)
+	require.NotEmpty(t, frames)
+	require.Equal(t, commonz.CallerInfo{
+		Package:  "github.com/goosz/commonz_test",
+		Function: "TestSymbolizePCs",
+	}, frames[0].CallerInfo)
+	require.Equal(t, file, frames[0].File)
+	require.Equal(t, line+1, frames[0].Line)
+	require.Equal(t, pcs[0], frames[0].PC)
+	require.False(t, frames[0].Inlined)
+	require.Equal(t, "tRunner", frames[1].Function)
+}
+
+func TestSymbolizePCs_inlined(t *testing.T) {
+	pcs := callInlinableCallers()
+	frames := commonz.SymbolizePCs(pcs)
+	require.Len(t, frames, len(pcs), "frames reported by the next program counter should not be repeated")
+
+	require.Equal(t, "inlinableCallers", frames[0].Function)
+	require.Equal(t, "callInlinableCallers", frames[1].Function)
+	require.True(t, frames[0].Inlined, "inlinableCallers should be inlined into callInlinableCallers")
+	require.False(t, frames[1].Inlined)
+	require.False(t, frames[2].Inlined)
+}
+
+func TestSymbolizePCs_inlinedRawPC(t *testing.T) {
+	// The first program counter alone, as in a stack trace listing only real program counters
+	pc := callInlinableCallers()[0]
+	frames := commonz.SymbolizePCs([]uintptr{pc})
+
+	require.Len(t, frames, 2)
+	require.Equal(t, "inlinableCallers", frames[0].Function)
+	require.True(t, frames[0].Inlined)
+	require.Equal(t, "callInlinableCallers", frames[1].Function)
+	require.False(t, frames[1].Inlined)
+	require.Equal(t, pc, frames[1].PC)
+
+	var s commonz.Symbolizer
+	require.Equal(t, frames, s.Symbolize([]uintptr{pc}))
+	require.Equal(t, frames, s.Symbolize([]uintptr{pc}), "cached resolution should match")
+	require.Equal(t, 1, s.Len())
+}
+
+func TestSymbolizePCs_unknown(t *testing.T) {
+	frames := commonz.SymbolizePCs([]uintptr{1})
+	require.Len(t, frames, 1)
+	require.True(t, frames[0].IsUnknown())
+	require.Equal(t, uintptr(1), frames[0].PC)
+}
+
+func TestSymbolizePCs_empty(t *testing.T) {
+	require.Empty(t, commonz.SymbolizePCs(nil))
+}
+
+func TestCallerFrame_String(t *testing.T) {
+	frame := commonz.CallerFrame{
+		CallerInfo: commonz.CallerInfo{Package: "github.com/user/package", Function: "(*MyStruct).SetValue"},
+		File:       "/src/package/file.go",
+		Line:       42,
+	}
+	require.Equal(t, "github.com/user/package.(*MyStruct).SetValue (/src/package/file.go:42)", frame.String())
+}
+
+func TestSymbolizer(t *testing.T) {
+	var s commonz.Symbolizer
+	first, second := callersHere(), callersHere()
+
+	batch := s.SymbolizeBatch([][]uintptr{first, second})
+	require.Len(t, batch, 2)
+	require.Equal(t, commonz.SymbolizePCs(first), batch[0])
+	require.Equal(t, commonz.SymbolizePCs(second), batch[1])
+
+	// The stacks differ only in the innermost program counter
+	require.Equal(t, len(first)+1, s.Len(), "shared program counters should be cached once")
+
+	require.Equal(t, batch[0], s.Symbolize(first), "cached resolution should match")
+	require.Equal(t, len(first)+1, s.Len())
+}
+
+func TestGetCallerFrameOutside(t *testing.T) {
+	_, file, line, _ := runtime.Caller(0)
+	frame := commonz.GetCallerFrameOutside(commonz.CurrentCaller)
+	require.Equal(t, "TestGetCallerFrameOutside", frame.Function)
+	require.Equal(t, file, frame.File)
+	require.Equal(t, line+1, frame.Line)
+
+	frame = commonz.GetCallerFrameOutside(commonz.CurrentCaller, "github.com/goosz/commonz_test")
+	require.Equal(t, commonz.CallerInfo{Package: "testing", Function: "tRunner"}, frame.CallerInfo)
+
+	require.True(t, commonz.GetCallerFrameOutside(-1).IsUnknown())
+	require.True(t, commonz.GetCallerFrameOutside(commonz.CurrentCaller,
+		"github.com/goosz/commonz_test", "testing", "runtime").IsUnknown())
+}
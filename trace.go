@@ -3,6 +3,7 @@ package commonz
 import (
 	"fmt"
 	"runtime"
+	"slices"
 	"strings"
 )
 
@@ -105,3 +106,54 @@ func GetCaller(depth int) CallerInfo {
 
 	return unknownCallerInfo()
 }
+
+// GetCallerOutside returns the CallerInfo of the first function at or above the specified
+// depth in the call stack that does not belong to any of the given packages.
+//
+// This is useful for library code that wants to identify its external caller regardless
+// of how many of its own functions are on the stack, for example:
+//
+//	caller := GetCallerOutside(CurrentCaller, "github.com/user/library")
+//
+// The depth parameter has the same meaning as for GetCaller. Package names are compared
+// exactly against CallerInfo.Package. If no such caller is found, returns a CallerInfo
+// for which IsUnknown() is true.
+func GetCallerOutside(depth int, packages ...string) CallerInfo {
+	if depth < 0 {
+		return unknownCallerInfo()
+	}
+	if frame, ok := callerFrameOutside(depth+1, packages); ok { // +1 to skip GetCallerOutside itself
+		return ParseCallerInfo(frame.Function)
+	}
+	return unknownCallerInfo()
+}
+
+// callerFrameOutside returns the first stack frame, starting skip frames above its caller,
+// whose function does not belong to any of the given packages.
+func callerFrameOutside(skip int, packages []string) (runtime.Frame, bool) {
+	pcs := callers(skip + 1) // +1 to skip callerFrameOutside itself
+
+	frames := runtime.CallersFrames(pcs)
+	for {
+		frame, more := frames.Next()
+		if frame.Function != "" && !slices.Contains(packages, ParseCallerInfo(frame.Function).Package) {
+			return frame, true
+		}
+		if !more {
+			return runtime.Frame{}, false
+		}
+	}
+}
+
+// callers returns the program counters of the entire stack, as reported by runtime.Callers,
+// skipping skip frames in addition to callers itself.
+func callers(skip int) []uintptr {
+	pcs := make([]uintptr, 32)
+	for {
+		n := runtime.Callers(skip+2, pcs) // +2 to skip runtime.Callers and callers itself
+		if n < len(pcs) {
+			return pcs[:n]
+		}
+		pcs = make([]uintptr, 2*len(pcs))
+	}
+}
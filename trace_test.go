@@ -678,3 +678,79 @@ func (ts *TestStruct) getCallerFromAnonymous() commonz.CallerInfo {
 		return commonz.GetCaller(commonz.CurrentCaller)
 	}()
 }
+
+// TestGetCallerOutside tests skipping the frames of given packages
+func TestGetCallerOutside(t *testing.T) {
+	tests := []struct {
+		name     string
+		depth    int
+		packages []string
+		expected commonz.CallerInfo
+	}{
+		{
+			name:     "no packages",
+			depth:    commonz.CurrentCaller,
+			packages: nil,
+			expected: commonz.CallerInfo{
+				Package:  "github.com/goosz/commonz_test",
+				Function: "callerOutsideHelper",
+			},
+		},
+		{
+			name:     "skip own package",
+			depth:    commonz.CurrentCaller,
+			packages: []string{"github.com/goosz/commonz_test"},
+			expected: commonz.CallerInfo{
+				Package:  "testing",
+				Function: "tRunner",
+			},
+		},
+		{
+			name:     "skip own and testing packages",
+			depth:    commonz.CurrentCaller,
+			packages: []string{"github.com/goosz/commonz_test", "testing"},
+			expected: commonz.CallerInfo{
+				Package:  "runtime",
+				Function: "goexit",
+			},
+		},
+		{
+			name:     "depth 1 - skip unrelated package",
+			depth:    commonz.ParentCaller,
+			packages: []string{"fmt"},
+			expected: commonz.CallerInfo{
+				Package:  "github.com/goosz/commonz_test",
+				Function: "TestGetCallerOutside.func1",
+			},
+		},
+		{
+			name:     "all packages skipped",
+			depth:    commonz.CurrentCaller,
+			packages: []string{"github.com/goosz/commonz_test", "testing", "runtime"},
+			expected: commonz.CallerInfo{
+				Package:  "<unknown-package>",
+				Function: "<unknown-function>",
+			},
+		},
+		{
+			name:  "negative depth",
+			depth: -1,
+			expected: commonz.CallerInfo{
+				Package:  "<unknown-package>",
+				Function: "<unknown-function>",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := callerOutsideHelper(tt.depth, tt.packages)
+			require.Equal(t, tt.expected, result, "GetCallerOutside should return the correct caller info")
+		})
+	}
+}
+
+// callerOutsideHelper calls GetCallerOutside from a named function
+func callerOutsideHelper(depth int, packages []string) commonz.CallerInfo {
+	return commonz.GetCallerOutside(depth, packages...)
+}
@@ -0,0 +1,166 @@
+package commonz
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"sync"
+)
+
+// TypeFactory maps stable type names to constructors of implementations of the interface
+// I, to encode and decode polymorphic JSON values of the form:
+//
+//	{"type": "github.com/user/app.Circle", "value": {"radius": 2}}
+//
+// The zero value is an empty factory ready to use. A TypeFactory is safe for concurrent use.
+type TypeFactory[I any] struct {
+	mu           sync.RWMutex
+	constructors map[string]func() I     // Guarded by mu
+	names        map[reflect.Type]string // Guarded by mu
+}
+
+// typedJSON is the JSON encoding of a value of a TypeFactory.
+type typedJSON struct {
+	Type  string          `json:"type"`
+	Value json.RawMessage `json:"value"`
+}
+
+// RegisterImplementation registers the type T, which must implement I, in f under its
+// TypeName, with a constructor returning a new zero T. Returns the name.
+//
+// T is typically a pointer type, such as *Circle, when I's methods have pointer
+// receivers, and may be a non-pointer type otherwise.
+func RegisterImplementation[I, T any](f *TypeFactory[I]) (string, error) {
+	name := TypeNameOf[T]()
+	if _, ok := any(*new(T)).(I); !ok {
+		return "", fmt.Errorf("%s does not implement %s", name, TypeNameOf[I]())
+	}
+	t := reflect.TypeFor[T]()
+	return name, f.Register(name, func() I {
+		if t.Kind() == reflect.Pointer {
+			return reflect.New(t.Elem()).Interface().(I)
+		}
+		return any(*new(T)).(I)
+	})
+}
+
+// Register registers a constructor under name. The constructor is called once on
+// registration to learn the type it constructs, so it must return a non-nil value of
+// the same concrete type on every call. Returns an error if name or the constructed type
+// is already registered.
+func (f *TypeFactory[I]) Register(name string, constructor func() I) error {
+	if name == "" {
+		return errors.New("cannot register empty type name")
+	}
+	t := reflect.TypeOf(constructor())
+	if t == nil {
+		return fmt.Errorf("constructor for %s returned nil", name)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.constructors[name]; ok {
+		return fmt.Errorf("type name %s is already registered", name)
+	}
+	if registered, ok := f.names[t]; ok {
+		return fmt.Errorf("type %s is already registered as %s", TypeName(t), registered)
+	}
+	if f.constructors == nil {
+		f.constructors = make(map[string]func() I)
+		f.names = make(map[reflect.Type]string)
+	}
+	f.constructors[name] = constructor
+	f.names[t] = name
+	return nil
+}
+
+// Names returns the registered type names, sorted.
+func (f *TypeFactory[I]) Names() []string {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	names := make([]string, 0, len(f.constructors))
+	for name := range f.constructors {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// New returns a new value of the type registered under name. If no type is registered
+// under name, the error wraps ErrUnknownType.
+func (f *TypeFactory[I]) New(name string) (I, error) {
+	f.mu.RLock()
+	constructor, ok := f.constructors[name]
+	f.mu.RUnlock()
+	if !ok {
+		var zero I
+		return zero, fmt.Errorf("%w %s for %s", ErrUnknownType, name, TypeNameOf[I]())
+	}
+	return constructor(), nil
+}
+
+// NameOf returns the name under which the dynamic type of v is registered.
+func (f *TypeFactory[I]) NameOf(v I) (string, error) {
+	t := reflect.TypeOf(v)
+	if t == nil {
+		return "", fmt.Errorf("nil %s has no type name", TypeNameOf[I]())
+	}
+	f.mu.RLock()
+	name, ok := f.names[t]
+	f.mu.RUnlock()
+	if !ok {
+		return "", fmt.Errorf("type %s is not registered for %s", TypeName(t), TypeNameOf[I]())
+	}
+	return name, nil
+}
+
+// Marshal encodes v as a JSON object holding the name of its registered type and its
+// own JSON encoding.
+func (f *TypeFactory[I]) Marshal(v I) ([]byte, error) {
+	name, err := f.NameOf(v)
+	if err != nil {
+		return nil, err
+	}
+	value, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("encoding %s: %w", name, err)
+	}
+	return json.Marshal(typedJSON{Type: name, Value: value})
+}
+
+// Unmarshal decodes a JSON object produced by Marshal into a new value of the type
+// registered under its name. If no type is registered under the name, the error wraps
+// ErrUnknownType.
+func (f *TypeFactory[I]) Unmarshal(data []byte) (I, error) {
+	var zero I
+	var typed typedJSON
+	if err := json.Unmarshal(data, &typed); err != nil {
+		return zero, err
+	}
+	if typed.Type == "" {
+		return zero, errors.New(`missing "type" in polymorphic JSON value`)
+	}
+	v, err := f.New(typed.Type)
+	if err != nil {
+		return zero, err
+	}
+	if len(typed.Value) == 0 {
+		return v, nil
+	}
+
+	// Decode into a pointer to the value, unless the value is itself a pointer.
+	target := reflect.ValueOf(v)
+	if target.Kind() != reflect.Pointer {
+		target = reflect.New(target.Type())
+		target.Elem().Set(reflect.ValueOf(v))
+	}
+	if err := json.Unmarshal(typed.Value, target.Interface()); err != nil {
+		return zero, fmt.Errorf("decoding %s: %w", typed.Type, err)
+	}
+	if reflect.ValueOf(v).Kind() != reflect.Pointer {
+		v = target.Elem().Interface().(I)
+	}
+	return v, nil
+}
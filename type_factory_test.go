@@ -0,0 +1,140 @@
+package commonz_test
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/goosz/commonz"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type Shape interface {
+	Area() float64
+}
+
+type Circle struct {
+	Radius float64 `json:"radius"`
+}
+
+func (c *Circle) Area() float64 { return math.Pi * c.Radius * c.Radius }
+
+type Square struct {
+	Side float64 `json:"side"`
+}
+
+func (s Square) Area() float64 { return s.Side * s.Side }
+
+func newShapeFactory(t *testing.T) *commonz.TypeFactory[Shape] {
+	var factory commonz.TypeFactory[Shape]
+	name, err := commonz.RegisterImplementation[Shape, *Circle](&factory)
+	require.NoError(t, err)
+	require.Equal(t, "*github.com/goosz/commonz_test.Circle", name)
+	require.NoError(t, factory.Register("square", func() Shape { return Square{} }))
+	return &factory
+}
+
+func TestTypeFactory(t *testing.T) {
+	factory := newShapeFactory(t)
+
+	tests := []struct {
+		name  string
+		shape Shape
+		json  string
+	}{
+		{
+			name:  "pointer type registered by TypeName",
+			shape: &Circle{Radius: 2},
+			json:  `{"type":"*github.com/goosz/commonz_test.Circle","value":{"radius":2}}`,
+		},
+		{
+			name:  "value type registered by custom name",
+			shape: Square{Side: 3},
+			json:  `{"type":"square","value":{"side":3}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := factory.Marshal(tt.shape)
+			require.NoError(t, err)
+			assert.JSONEq(t, tt.json, string(data))
+
+			decoded, err := factory.Unmarshal(data)
+			require.NoError(t, err)
+			assert.Equal(t, tt.shape, decoded)
+		})
+	}
+}
+
+func TestTypeFactory_newValues(t *testing.T) {
+	factory := newShapeFactory(t)
+
+	assert.Equal(t, []string{"*github.com/goosz/commonz_test.Circle", "square"}, factory.Names())
+
+	first, err := factory.New("*github.com/goosz/commonz_test.Circle")
+	require.NoError(t, err)
+	second, err := factory.New("*github.com/goosz/commonz_test.Circle")
+	require.NoError(t, err)
+	assert.Equal(t, &Circle{}, first)
+	assert.NotSame(t, first, second, "each call constructs a new value")
+
+	decoded, err := factory.Unmarshal([]byte(`{"type":"square"}`))
+	require.NoError(t, err)
+	assert.Equal(t, Square{}, decoded, "a missing value decodes as the constructed value")
+
+	name, err := factory.NameOf(Square{Side: 1})
+	require.NoError(t, err)
+	assert.Equal(t, "square", name)
+}
+
+type Triangle struct{}
+
+func (Triangle) Area() float64 { return 0 }
+
+func TestTypeFactory_errors(t *testing.T) {
+	factory := newShapeFactory(t)
+
+	_, err := factory.Unmarshal([]byte(`{"type":"hexagon","value":{}}`))
+	assert.EqualError(t, err, "unknown type hexagon for github.com/goosz/commonz_test.Shape")
+	assert.True(t, errors.Is(err, commonz.ErrUnknownType))
+
+	_, err = factory.New("hexagon")
+	assert.True(t, errors.Is(err, commonz.ErrUnknownType))
+
+	_, err = factory.Unmarshal([]byte(`{"value":{}}`))
+	assert.EqualError(t, err, `missing "type" in polymorphic JSON value`)
+
+	_, err = factory.Unmarshal([]byte(`{"type":"square","value":{"side":"wide"}}`))
+	assert.ErrorContains(t, err, "decoding square: json: cannot unmarshal string")
+
+	_, err = factory.Unmarshal([]byte(`not json`))
+	assert.Error(t, err)
+
+	_, err = factory.Marshal(Triangle{})
+	assert.EqualError(t, err, "type github.com/goosz/commonz_test.Triangle is not registered for github.com/goosz/commonz_test.Shape")
+
+	_, err = factory.Marshal(nil)
+	assert.EqualError(t, err, "nil github.com/goosz/commonz_test.Shape has no type name")
+}
+
+func TestTypeFactory_registrationErrors(t *testing.T) {
+	factory := newShapeFactory(t)
+
+	_, err := commonz.RegisterImplementation[Shape, Circle](factory)
+	assert.EqualError(t, err, "github.com/goosz/commonz_test.Circle does not implement github.com/goosz/commonz_test.Shape",
+		"Circle implements Shape with a pointer receiver")
+
+	_, err = commonz.RegisterImplementation[Shape, *Circle](factory)
+	assert.EqualError(t, err, "type name *github.com/goosz/commonz_test.Circle is already registered")
+
+	err = factory.Register("box", func() Shape { return Square{} })
+	assert.EqualError(t, err, "type github.com/goosz/commonz_test.Square is already registered as square")
+
+	err = factory.Register("nothing", func() Shape { return nil })
+	assert.EqualError(t, err, "constructor for nothing returned nil")
+
+	err = factory.Register("", func() Shape { return Triangle{} })
+	assert.EqualError(t, err, "cannot register empty type name")
+}
@@ -0,0 +1,267 @@
+package commonz
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ErrUnknownType is returned, wrapped, when a type name refers to a type that is neither
+// predeclared nor registered.
+var ErrUnknownType = errors.New("unknown type")
+
+// Limits on the types that reflect can construct, beyond which its constructors panic.
+const (
+	maxChanElemSize = 1 << 16 // The exclusive limit on the size of channel elements
+	maxFuncArgs     = 128     // The limit on the number of parameters and results of functions
+)
+
+// maxTypeNameNesting limits the nesting of the types in a name parsed by a TypeRegistry, so
+// that names from untrusted sources cannot exhaust the stack.
+const maxTypeNameNesting = 64
+
+// predeclaredTypes are the types that a TypeRegistry resolves without registration, by name.
+var predeclaredTypes = map[string]reflect.Type{
+	"bool":         reflect.TypeFor[bool](),
+	"int":          reflect.TypeFor[int](),
+	"int8":         reflect.TypeFor[int8](),
+	"int16":        reflect.TypeFor[int16](),
+	"int32":        reflect.TypeFor[int32](),
+	"int64":        reflect.TypeFor[int64](),
+	"uint":         reflect.TypeFor[uint](),
+	"uint8":        reflect.TypeFor[uint8](),
+	"uint16":       reflect.TypeFor[uint16](),
+	"uint32":       reflect.TypeFor[uint32](),
+	"uint64":       reflect.TypeFor[uint64](),
+	"uintptr":      reflect.TypeFor[uintptr](),
+	"float32":      reflect.TypeFor[float32](),
+	"float64":      reflect.TypeFor[float64](),
+	"complex64":    reflect.TypeFor[complex64](),
+	"complex128":   reflect.TypeFor[complex128](),
+	"string":       reflect.TypeFor[string](),
+	"error":        reflect.TypeFor[error](),
+	"byte":         reflect.TypeFor[byte](),
+	"rune":         reflect.TypeFor[rune](),
+	"any":          reflect.TypeFor[any](),
+	"interface {}": reflect.TypeFor[any](),
+	"struct {}":    reflect.TypeFor[struct{}](),
+}
+
+// TypeRegistry resolves type names produced by TypeName back into types. Named types
+// must be registered, while predeclared types and the composite types built from known
+// types, such as "[]map[string]*github.com/user/app.User", are resolved without
+// registration. Anonymous struct and interface types other than "struct {}" and
+// "interface {}" cannot be resolved, since reflect cannot construct them faithfully.
+//
+// The zero value is an empty registry ready to use. A TypeRegistry is safe for
+// concurrent use.
+type TypeRegistry struct {
+	mu    sync.RWMutex
+	types map[string]reflect.Type // Guarded by mu
+}
+
+// RegisterType registers the type parameter T in r, returning its name. See
+// TypeRegistry.Register.
+func RegisterType[T any](r *TypeRegistry) (string, error) {
+	t := reflect.TypeFor[T]()
+	return TypeName(t), r.Register(t)
+}
+
+// Register registers t under its TypeName. Registering a type more than once has no
+// effect, but registering a different type under the same name, as may happen for types
+// declared inside functions, returns an error.
+func (r *TypeRegistry) Register(t reflect.Type) error {
+	if t == nil {
+		return errors.New("cannot register nil type")
+	}
+	name := TypeName(t)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if registered, ok := r.types[name]; ok && registered != t {
+		return fmt.Errorf("another type is already registered as %s", name)
+	}
+	if r.types == nil {
+		r.types = make(map[string]reflect.Type)
+	}
+	r.types[name] = t
+	return nil
+}
+
+// Lookup returns the type whose TypeName is name. If name refers to a type that is
+// neither predeclared nor registered, the error wraps ErrUnknownType.
+func (r *TypeRegistry) Lookup(name string) (reflect.Type, error) {
+	t, rest, err := r.parse(name, 0)
+	if err == nil && rest != "" {
+		err = fmt.Errorf("unexpected %q", rest)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("type %q: %w", name, err)
+	}
+	return t, nil
+}
+
+// lookupNamed returns the predeclared or registered type with the given name.
+func (r *TypeRegistry) lookupNamed(name string) (reflect.Type, error) {
+	if t, ok := predeclaredTypes[name]; ok {
+		return t, nil
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if t, ok := r.types[name]; ok {
+		return t, nil
+	}
+	return nil, fmt.Errorf("%w %s", ErrUnknownType, name)
+}
+
+// parse parses the type at the start of s, as rendered by TypeName, returning the type
+// and the remainder of s. The depth is the number of types the type is nested in.
+func (r *TypeRegistry) parse(s string, depth int) (reflect.Type, string, error) {
+	if depth > maxTypeNameNesting {
+		return nil, "", fmt.Errorf("types nested deeper than %d levels", maxTypeNameNesting)
+	}
+	switch {
+	case strings.HasPrefix(s, "*"):
+		elem, rest, err := r.parse(s[len("*"):], depth+1)
+		if err != nil {
+			return nil, "", err
+		}
+		return reflect.PointerTo(elem), rest, nil
+	case strings.HasPrefix(s, "[]"):
+		elem, rest, err := r.parse(s[len("[]"):], depth+1)
+		if err != nil {
+			return nil, "", err
+		}
+		return reflect.SliceOf(elem), rest, nil
+	case strings.HasPrefix(s, "["):
+		length, rest, ok := strings.Cut(s[len("["):], "]")
+		// Unlike Atoi, ParseUint rejects signs, which TypeName never renders.
+		n, err := strconv.ParseUint(length, 10, strconv.IntSize-1)
+		if !ok || err != nil {
+			return nil, "", fmt.Errorf("invalid array length in %q", s)
+		}
+		elem, rest, err := r.parse(rest, depth+1)
+		if err != nil {
+			return nil, "", err
+		}
+		if elem.Size() > 0 && uintptr(n) > ^uintptr(0)/elem.Size() {
+			return nil, "", fmt.Errorf("array of %d %s is too large", n, TypeName(elem))
+		}
+		return reflect.ArrayOf(int(n), elem), rest, nil
+	case strings.HasPrefix(s, "map["):
+		key, rest, err := r.parse(s[len("map["):], depth+1)
+		if err != nil {
+			return nil, "", err
+		}
+		if !strings.HasPrefix(rest, "]") {
+			return nil, "", fmt.Errorf("expected ] after map key in %q", s)
+		}
+		if !key.Comparable() {
+			return nil, "", fmt.Errorf("invalid map key type %s", TypeName(key))
+		}
+		elem, rest, err := r.parse(rest[len("]"):], depth+1)
+		if err != nil {
+			return nil, "", err
+		}
+		return reflect.MapOf(key, elem), rest, nil
+	case strings.HasPrefix(s, "chan<- "):
+		return r.parseChan(s[len("chan<- "):], reflect.SendDir, depth)
+	case strings.HasPrefix(s, "<-chan "):
+		return r.parseChan(s[len("<-chan "):], reflect.RecvDir, depth)
+	case strings.HasPrefix(s, "chan "):
+		return r.parseChan(s[len("chan "):], reflect.BothDir, depth)
+	case strings.HasPrefix(s, "func("):
+		return r.parseFunc(s[len("func("):], depth)
+	case strings.HasPrefix(s, "struct {}"):
+		return predeclaredTypes["struct {}"], s[len("struct {}"):], nil
+	case strings.HasPrefix(s, "interface {}"):
+		return predeclaredTypes["interface {}"], s[len("interface {}"):], nil
+	case strings.HasPrefix(s, "struct {"), strings.HasPrefix(s, "interface {"):
+		return nil, "", fmt.Errorf("anonymous struct and interface types are not supported")
+	}
+
+	end := 0
+	for end < len(s) && isTypeNameByte(s[end]) {
+		end++
+	}
+	if end < len(s) && s[end] == '[' {
+		// The type arguments of an instantiated generic type are part of its name.
+		end = typeArgsEnd(s, end)
+	}
+	if end == 0 {
+		return nil, "", fmt.Errorf("expected a type at %q", s)
+	}
+	t, err := r.lookupNamed(s[:end])
+	return t, s[end:], err
+}
+
+// parseChan parses the element type of a channel type with the given direction, nested
+// at the given depth.
+func (r *TypeRegistry) parseChan(s string, dir reflect.ChanDir, depth int) (reflect.Type, string, error) {
+	elem, rest, err := r.parse(s, depth+1)
+	if err != nil {
+		return nil, "", err
+	}
+	if elem.Size() >= maxChanElemSize {
+		return nil, "", fmt.Errorf("channel element type %s is too large", TypeName(elem))
+	}
+	return reflect.ChanOf(dir, elem), rest, nil
+}
+
+// parseFunc parses a function type following "func(", nested at the given depth: its
+// parameters, and its results if any.
+func (r *TypeRegistry) parseFunc(s string, depth int) (reflect.Type, string, error) {
+	in, variadic, rest, err := r.parseList(s, true, depth+1)
+	if err != nil {
+		return nil, "", err
+	}
+	var out []reflect.Type
+	switch {
+	case strings.HasPrefix(rest, " ("):
+		out, _, rest, err = r.parseList(rest[len(" ("):], false, depth+1)
+	case strings.HasPrefix(rest, " "):
+		var result reflect.Type
+		result, rest, err = r.parse(rest[len(" "):], depth+1)
+		out = []reflect.Type{result}
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	if len(in)+len(out) > maxFuncArgs {
+		return nil, "", fmt.Errorf("function with %d parameters and results has too many", len(in)+len(out))
+	}
+	return reflect.FuncOf(in, out, variadic), rest, nil
+}
+
+// parseList parses a comma-separated list of types at the given depth, terminated by ")".
+// If params is set, the last type may be variadic, rendered as "...T", in which case the
+// returned slice holds []T in its place.
+func (r *TypeRegistry) parseList(s string, params bool, depth int) ([]reflect.Type, bool, string, error) {
+	var types []reflect.Type
+	variadic := false
+	for !strings.HasPrefix(s, ")") {
+		if len(types) > 0 {
+			if !strings.HasPrefix(s, ", ") || variadic {
+				return nil, false, "", fmt.Errorf("expected ) at %q", s)
+			}
+			s = s[len(", "):]
+		}
+		if params && strings.HasPrefix(s, "...") {
+			variadic = true
+			s = s[len("..."):]
+		}
+		t, rest, err := r.parse(s, depth)
+		if err != nil {
+			return nil, false, "", err
+		}
+		if variadic {
+			t = reflect.SliceOf(t)
+		}
+		types = append(types, t)
+		s = rest
+	}
+	return types, variadic, s[len(")"):], nil
+}
@@ -0,0 +1,218 @@
+package commonz_test
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/goosz/commonz"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type registryUser struct {
+	Name string
+}
+
+type registryID int64
+
+func TestTypeRegistry(t *testing.T) {
+	var registry commonz.TypeRegistry
+	for _, register := range []func(*commonz.TypeRegistry) (string, error){
+		commonz.RegisterType[registryUser],
+		commonz.RegisterType[registryID],
+		commonz.RegisterType[time.Duration],
+		commonz.RegisterType[Box[registryUser]],
+		commonz.RegisterType[Pair[int, Box[registryID]]],
+		commonz.RegisterType[Interface],
+	} {
+		_, err := register(&registry)
+		require.NoError(t, err)
+	}
+
+	tests := []struct {
+		name     string
+		expected reflect.Type
+	}{
+		{"predeclared", reflect.TypeFor[int]()},
+		{"error", reflect.TypeFor[error]()},
+		{"empty interface", reflect.TypeFor[any]()},
+		{"empty struct", reflect.TypeFor[struct{}]()},
+		{"registered", reflect.TypeFor[registryUser]()},
+		{"registered interface", reflect.TypeFor[Interface]()},
+		{"registered generic", reflect.TypeFor[Box[registryUser]]()},
+		{"registered generic with nested arguments", reflect.TypeFor[Pair[int, Box[registryID]]]()},
+		{"pointer", reflect.TypeFor[*registryUser]()},
+		{"slice", reflect.TypeFor[[]registryUser]()},
+		{"array", reflect.TypeFor[[4]registryID]()},
+		{"large array", reflect.TypeFor[[1 << 40]byte]()},
+		{"channel of large elements", reflect.TypeFor[chan [1<<16 - 1]byte]()},
+		{"map", reflect.TypeFor[map[registryID]*registryUser]()},
+		{"nested composites", reflect.TypeFor[[]map[string]registryUser]()},
+		{"channels", reflect.TypeFor[map[string]chan<- <-chan registryID]()},
+		{"bidirectional channel", reflect.TypeFor[chan []byte]()},
+		{"function", reflect.TypeFor[func(registryID, time.Duration)]()},
+		{"function with result", reflect.TypeFor[func() *registryUser]()},
+		{"function with results", reflect.TypeFor[func(string) (registryUser, error)]()},
+		{"variadic function", reflect.TypeFor[func(string, ...registryID) error]()},
+		{"function parameters and results", reflect.TypeFor[func(func(int) error, []func() (int, bool)) func() string]()},
+		{"composite of generic", reflect.TypeFor[[]*Box[registryUser]]()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name := commonz.TypeName(tt.expected)
+			actual, err := registry.Lookup(name)
+			require.NoError(t, err, name)
+			assert.Equal(t, tt.expected, actual, name)
+		})
+	}
+}
+
+func TestTypeRegistry_errors(t *testing.T) {
+	var registry commonz.TypeRegistry
+	_, err := commonz.RegisterType[registryUser](&registry)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		input   string
+		error   string
+		unknown bool
+	}{
+		{
+			name:    "unknown type",
+			input:   "github.com/goosz/commonz_test.Missing",
+			error:   `type "github.com/goosz/commonz_test.Missing": unknown type github.com/goosz/commonz_test.Missing`,
+			unknown: true,
+		},
+		{
+			name:    "unknown element type",
+			input:   "[]map[string]github.com/goosz/commonz_test.Missing",
+			error:   `type "[]map[string]github.com/goosz/commonz_test.Missing": unknown type github.com/goosz/commonz_test.Missing`,
+			unknown: true,
+		},
+		{
+			name:    "unregistered instantiation",
+			input:   "github.com/goosz/commonz_test.Box[int]",
+			error:   `type "github.com/goosz/commonz_test.Box[int]": unknown type github.com/goosz/commonz_test.Box[int]`,
+			unknown: true,
+		},
+		{
+			name:  "empty",
+			input: "",
+			error: `type "": expected a type at ""`,
+		},
+		{
+			name:  "trailing text",
+			input: "int]",
+			error: `type "int]": unexpected "]"`,
+		},
+		{
+			name:  "invalid map key",
+			input: "map[[]int]string",
+			error: `type "map[[]int]string": invalid map key type []int`,
+		},
+		{
+			name:  "invalid array length",
+			input: "[n]int",
+			error: `type "[n]int": invalid array length in "[n]int"`,
+		},
+		{
+			name:  "signed array length",
+			input: "[+5]int",
+			error: `type "[+5]int": invalid array length in "[+5]int"`,
+		},
+		{
+			name:  "negative array length",
+			input: "[-1]int",
+			error: `type "[-1]int": invalid array length in "[-1]int"`,
+		},
+		{
+			name:  "array too large",
+			input: "[9223372036854775807]int",
+			error: `type "[9223372036854775807]int": array of 9223372036854775807 int is too large`,
+		},
+		{
+			name:  "nested array too large",
+			input: "[4294967296][4294967296]int",
+			error: `type "[4294967296][4294967296]int": array of 4294967296 [4294967296]int is too large`,
+		},
+		{
+			name:  "channel element too large",
+			input: "chan [70000]uint8",
+			error: `type "chan [70000]uint8": channel element type [70000]uint8 is too large`,
+		},
+		{
+			name:  "function with too many parameters",
+			input: "func(" + strings.Repeat("int, ", 128) + "int)",
+			error: `type "func(` + strings.Repeat("int, ", 128) + `int)": function with 129 parameters and results has too many`,
+		},
+		{
+			name:  "nesting too deep",
+			input: strings.Repeat("*", 65) + "int",
+			error: `type "` + strings.Repeat("*", 65) + `int": types nested deeper than 64 levels`,
+		},
+		{
+			name:  "nesting too deep in function",
+			input: strings.Repeat("func(", 65) + "int" + strings.Repeat(")", 65),
+			error: `type "` + strings.Repeat("func(", 65) + "int" + strings.Repeat(")", 65) + `": types nested deeper than 64 levels`,
+		},
+		{
+			name:  "unterminated function",
+			input: "func(int",
+			error: `type "func(int": expected ) at ""`,
+		},
+		{
+			name:  "anonymous struct",
+			input: "struct { Name string }",
+			error: `type "struct { Name string }": anonymous struct and interface types are not supported`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := registry.Lookup(tt.input)
+			require.EqualError(t, err, tt.error)
+			assert.Equal(t, tt.unknown, errors.Is(err, commonz.ErrUnknownType))
+		})
+	}
+}
+
+func TestTypeRegistry_deepNesting(t *testing.T) {
+	var registry commonz.TypeRegistry
+	typ, err := registry.Lookup(strings.Repeat("*", 64) + "int")
+	require.NoError(t, err)
+	require.Equal(t, strings.Repeat("*", 64)+"int", commonz.TypeName(typ))
+
+	for _, prefix := range []string{"*", "[]", "[1]", "map[int]", "chan ", "func() "} {
+		_, err := registry.Lookup(strings.Repeat(prefix, 1_000_000) + "int")
+		require.ErrorContains(t, err, "types nested deeper than 64 levels", prefix)
+	}
+}
+
+func TestTypeRegistry_register(t *testing.T) {
+	var registry commonz.TypeRegistry
+
+	name, err := commonz.RegisterType[registryUser](&registry)
+	require.NoError(t, err)
+	assert.Equal(t, "github.com/goosz/commonz_test.registryUser", name)
+
+	_, err = commonz.RegisterType[registryUser](&registry)
+	assert.NoError(t, err, "registering a type again has no effect")
+
+	// Types declared in different functions may share a TypeName.
+	type local struct{}
+	first := reflect.TypeFor[local]()
+	second := func() reflect.Type {
+		type local struct{ _ int }
+		return reflect.TypeFor[local]()
+	}()
+	require.Equal(t, commonz.TypeName(first), commonz.TypeName(second))
+	require.NoError(t, registry.Register(first))
+	assert.EqualError(t, registry.Register(second), "another type is already registered as github.com/goosz/commonz_test.local")
+
+	assert.EqualError(t, registry.Register(nil), "cannot register nil type")
+}
@@ -3,82 +3,222 @@ package commonz
 import (
 	"fmt"
 	"reflect"
+	"strconv"
+	"strings"
+	"sync"
 )
 
-// Prevent stack overflow with deeply nested types.
-// This should be more than enough for reasonable uses.
+// TypeQualifier selects how a TypeFormatter qualifies named types with their package.
+type TypeQualifier int
+
+const (
+	QualifyImportPath  TypeQualifier = iota // Qualify with the full import path, e.g. "github.com/user/app/model.User"
+	QualifyPackageName                      // Qualify with the package name, e.g. "model.User"
+	QualifyNone                             // Do not qualify, e.g. "User"
+)
+
+// TypeFormatter renders Go types as human-readable strings. Its fields configure how
+// named types are qualified and how much of a type is rendered.
 //
-// Most real-world Go code rarely exceeds 4-5 levels of nesting, making 8 a safe
-// upper bound that prevents stack overflow while preserving meaningful type information.
-const maxTypeDepth = 8
+// The zero value renders types the same way as TypeName.
+type TypeFormatter struct {
+	// Qualifier selects how named types are qualified with their package.
+	Qualifier TypeQualifier
+
+	// MaxDepth, if positive, limits how deeply nested types are rendered; deeper types
+	// are rendered as "<...>". By default, types are rendered in full: unnamed types
+	// cannot be recursive, and recursive references are cut short when ExpandNamed is set.
+	MaxDepth int
+
+	// CollapseAnonymous renders the bodies of anonymous struct and interface types as
+	// "{...}" instead of listing their fields and methods.
+	CollapseAnonymous bool
+
+	// StructTags includes the tags of fields in the bodies of anonymous struct types.
+	StructTags bool
+
+	// ExpandNamed renders the bodies of named struct and interface types after their
+	// names, e.g. "app.Node struct { Value int; Next *<cycle app.Node> }". A recursive
+	// reference to a type whose body is being rendered is rendered as a back-reference
+	// marker rather than expanded again.
+	ExpandNamed bool
+
+	// PackageAliases maps import paths to the qualifier used for their types in place of
+	// the one selected by Qualifier, e.g. to abbreviate a long path or, with an empty
+	// alias, to leave the types of the package being inspected unqualified. Aliases are
+	// not used with QualifyNone.
+	PackageAliases map[string]string
+
+	// Override, if not nil, is called for every type to be rendered, including the
+	// types nested within it. If it returns ok, name is used as the rendering of t.
+	Override func(t reflect.Type) (name string, ok bool)
+
+	// expanding holds the named types whose bodies are being rendered, when ExpandNamed is set.
+	expanding map[reflect.Type]bool
+}
 
 // TypeName returns a human-readable string representation of a Go type.
-// Unlike [reflect.Type]'s String() implementation, this function includes
-// package paths for structs and interfaces when available, making type
+// Unlike [reflect.Type]'s String() implementation, this function qualifies
+// every named type with its full package path, whatever its kind, making type
 // identification clearer in multi-package contexts.
 //
 // The function handles all Go types including basic types, composite types,
-// function types, generic types, and channels. See the examples for usage.
+// function types, generic types, and channels. The bodies of anonymous struct
+// and interface types are rendered with the same qualification, e.g.
+// "struct { User github.com/user/app.User }". See the examples for usage.
+// TypeName is the default configuration of TypeFormatter.
 func TypeName(t reflect.Type) string {
+	return TypeFormatter{}.Format(t)
+}
+
+// typeNames caches the results of TypeNameOf and TypeNameOfValue.
+var typeNames sync.Map // map[reflect.Type]string
+
+// TypeNameOf returns the TypeName of the type parameter T. Unlike reflect.TypeOf, it
+// works for interface types, e.g. TypeNameOf[error]() returns "error".
+//
+// Results are cached per type, so repeated calls are cheap.
+func TypeNameOf[T any]() string {
+	return cachedTypeName(reflect.TypeFor[T]())
+}
+
+// TypeNameOfValue returns the TypeName of the dynamic type of v, or "<nil>" if v is nil.
+// Since an interface value holds a concrete type, the result is never an interface type;
+// use TypeNameOf for the static type of a variable.
+//
+// Results are cached per type, so repeated calls are cheap.
+func TypeNameOfValue(v any) string {
+	return cachedTypeName(reflect.TypeOf(v))
+}
+
+// cachedTypeName returns TypeName(t), using the typeNames cache.
+func cachedTypeName(t reflect.Type) string {
+	if t == nil {
+		return TypeName(nil)
+	}
+	if name, ok := typeNames.Load(t); ok {
+		return name.(string)
+	}
+	name, _ := typeNames.LoadOrStore(t, TypeName(t))
+	return name.(string)
+}
+
+// Format returns the string representation of t, or "<nil>" if t is nil.
+func (tf TypeFormatter) Format(t reflect.Type) string {
 	// Handle nil type
 	if t == nil {
 		return "<nil>"
 	}
-	return typeNameWithDepth(t, maxTypeDepth)
+	maxDepth := tf.MaxDepth
+	if maxDepth <= 0 {
+		maxDepth = -1 // Never reaches zero
+	}
+	if tf.ExpandNamed {
+		tf.expanding = make(map[reflect.Type]bool)
+	}
+	return tf.format(t, maxDepth)
+}
+
+func typeNameWithDepth(t reflect.Type, maxDepth int) string {
+	return TypeFormatter{}.format(t, maxDepth)
 }
 
-func typeNameForFunc(t reflect.Type, maxDepth int) string {
-	paramTypes := make([]string, t.NumIn())
+// formatSignature returns the parameters and results of a function type, as in its
+// declaration following "func" or the method name.
+func (tf TypeFormatter) formatSignature(t reflect.Type, maxDepth int) string {
+	params := make([]string, t.NumIn())
 	for i := range t.NumIn() {
 		if t.IsVariadic() && i == t.NumIn()-1 {
-			paramTypes[i] = "..." + typeNameWithDepth(t.In(i).Elem(), maxDepth-1)
+			params[i] = "..." + tf.format(t.In(i).Elem(), maxDepth-1)
 		} else {
-			paramTypes[i] = typeNameWithDepth(t.In(i), maxDepth-1)
+			params[i] = tf.format(t.In(i), maxDepth-1)
 		}
 	}
-	params := ""
-	if len(paramTypes) > 0 {
-		params = paramTypes[0]
-		for i := 1; i < len(paramTypes); i++ {
-			params += ", " + paramTypes[i]
-		}
-	}
-	if t.NumOut() > 0 {
-		returnTypes := make([]string, t.NumOut())
+	signature := fmt.Sprintf("(%s)", strings.Join(params, ", "))
+	switch t.NumOut() {
+	case 0:
+		return signature
+	case 1:
+		return signature + " " + tf.format(t.Out(0), maxDepth-1)
+	default:
+		returns := make([]string, t.NumOut())
 		for i := range t.NumOut() {
-			returnTypes[i] = typeNameWithDepth(t.Out(i), maxDepth-1)
+			returns[i] = tf.format(t.Out(i), maxDepth-1)
 		}
-		var returns string
-		if len(returnTypes) > 1 {
-			returns += "("
-		}
-		returns += returnTypes[0]
-		for i := 1; i < len(returnTypes); i++ {
-			returns += ", " + returnTypes[i]
+		return fmt.Sprintf("%s (%s)", signature, strings.Join(returns, ", "))
+	}
+}
+
+// formatStruct renders the body of a struct type, e.g.
+// `struct { Name string; Embedded; ID int "json:\"id\"" }`.
+func (tf TypeFormatter) formatStruct(t reflect.Type, maxDepth int) string {
+	if t.NumField() == 0 {
+		return "struct {}"
+	}
+	if tf.CollapseAnonymous {
+		return "struct {...}"
+	}
+	fields := make([]string, t.NumField())
+	for i := range t.NumField() {
+		field := t.Field(i)
+		fields[i] = tf.format(field.Type, maxDepth-1)
+		if !field.Anonymous {
+			fields[i] = field.Name + " " + fields[i]
 		}
-		if len(returnTypes) > 1 {
-			returns += ")"
+		if tf.StructTags && field.Tag != "" {
+			fields[i] += " " + strconv.Quote(string(field.Tag))
 		}
-		return fmt.Sprintf("func(%s) %s", params, returns)
 	}
-	return fmt.Sprintf("func(%s)", params)
+	return fmt.Sprintf("struct { %s }", strings.Join(fields, "; "))
 }
 
-func typeNameWithDepth(t reflect.Type, maxDepth int) string {
-	// Prevent stack overflows with deeply nested types
+// formatInterface renders the method set of an interface type, including the
+// methods of embedded interfaces, e.g. "interface { Close() error; Read([]uint8) (int, error) }".
+func (tf TypeFormatter) formatInterface(t reflect.Type, maxDepth int) string {
+	if t.NumMethod() == 0 {
+		return "interface {}"
+	}
+	if tf.CollapseAnonymous {
+		return "interface {...}"
+	}
+	methods := make([]string, t.NumMethod())
+	for i := range t.NumMethod() {
+		method := t.Method(i)
+		methods[i] = method.Name + tf.formatSignature(method.Type, maxDepth)
+	}
+	return fmt.Sprintf("interface { %s }", strings.Join(methods, "; "))
+}
+
+func (tf TypeFormatter) format(t reflect.Type, maxDepth int) string {
+	// Limit the rendering of deeply nested types; a negative depth never reaches zero
 	if maxDepth == 0 {
 		return "<...>"
 	}
 
+	if tf.Override != nil {
+		if name, ok := tf.Override(t); ok {
+			return name
+		}
+	}
+
+	// Named types are qualified with their package path, whatever their kind.
+	// Predeclared types such as int and error have no package path.
+	if t.Name() != "" {
+		if t.PkgPath() == "" {
+			return t.Name()
+		}
+		return tf.formatNamed(t, maxDepth)
+	}
+
 	switch t.Kind() {
 	case reflect.Array:
-		return fmt.Sprintf("[%d]%s", t.Len(), typeNameWithDepth(t.Elem(), maxDepth-1))
+		return fmt.Sprintf("[%d]%s", t.Len(), tf.format(t.Elem(), maxDepth-1))
 	case reflect.Slice:
-		return fmt.Sprintf("[]%s", typeNameWithDepth(t.Elem(), maxDepth-1))
+		return fmt.Sprintf("[]%s", tf.format(t.Elem(), maxDepth-1))
 	case reflect.Map:
 		return fmt.Sprintf("map[%s]%s",
-			typeNameWithDepth(t.Key(), maxDepth-1),
-			typeNameWithDepth(t.Elem(), maxDepth-1))
+			tf.format(t.Key(), maxDepth-1),
+			tf.format(t.Elem(), maxDepth-1))
 	case reflect.Chan:
 		dir := ""
 		switch t.ChanDir() {
@@ -89,17 +229,210 @@ func typeNameWithDepth(t reflect.Type, maxDepth int) string {
 		default:
 			dir = "chan "
 		}
-		return dir + typeNameWithDepth(t.Elem(), maxDepth-1)
+		return dir + tf.format(t.Elem(), maxDepth-1)
 	case reflect.Ptr:
-		return "*" + typeNameWithDepth(t.Elem(), maxDepth-1)
-	case reflect.Struct, reflect.Interface:
-		if t.PkgPath() == "" {
-			return t.String()
-		}
-		return fmt.Sprintf("%s.%s", t.PkgPath(), t.Name())
+		return "*" + tf.format(t.Elem(), maxDepth-1)
+	case reflect.Struct:
+		return tf.formatStruct(t, maxDepth)
+	case reflect.Interface:
+		return tf.formatInterface(t, maxDepth)
 	case reflect.Func:
-		return typeNameForFunc(t, maxDepth)
+		return "func" + tf.formatSignature(t, maxDepth)
 	default:
 		return t.String() // Use reflect.Type's own String() implementation for everything else
 	}
 }
+
+// formatNamed renders a named type declared in a package, followed by its body if
+// ExpandNamed is set and it is a struct or interface type.
+func (tf TypeFormatter) formatNamed(t reflect.Type, maxDepth int) string {
+	name := tf.qualify(t)
+	if !tf.ExpandNamed || t.Kind() != reflect.Struct && t.Kind() != reflect.Interface {
+		return name
+	}
+	if tf.expanding[t] {
+		return "<cycle " + name + ">"
+	}
+	tf.expanding[t] = true
+	defer delete(tf.expanding, t)
+	if t.Kind() == reflect.Struct {
+		return name + " " + tf.formatStruct(t, maxDepth)
+	}
+	return name + " " + tf.formatInterface(t, maxDepth)
+}
+
+// qualify returns the name of a named type, qualified as configured, with the type
+// arguments of instantiated generic types rendered by formatTypeArgs.
+func (tf TypeFormatter) qualify(t reflect.Type) string {
+	// reflect.Type's String() qualifies named types with their package name.
+	pkgName, _, _ := strings.Cut(t.String(), ".")
+	name, args, generic := strings.Cut(t.Name(), "[")
+	name = tf.qualifyName(t.PkgPath(), pkgName, name)
+	if generic {
+		name += tf.formatTypeArgs(strings.TrimSuffix(args, "]"))
+	}
+	return name
+}
+
+// qualifyName qualifies the name of a type declared in the package with the given
+// import path and name, as configured. If pkgName is empty, it is derived from pkgPath.
+func (tf TypeFormatter) qualifyName(pkgPath, pkgName, name string) string {
+	if tf.Qualifier == QualifyNone {
+		return name
+	}
+	qualifier, ok := tf.PackageAliases[pkgPath]
+	if !ok {
+		switch {
+		case tf.Qualifier != QualifyPackageName:
+			qualifier = pkgPath
+		case pkgName != "":
+			qualifier = pkgName
+		default:
+			qualifier = packageNameFromPath(pkgPath)
+		}
+	}
+	if qualifier == "" {
+		return name
+	}
+	return qualifier + "." + name
+}
+
+// packageNameFromPath guesses the name of a package from its import path: its last
+// element, without a major version suffix such as "/v2" or ".v3".
+func packageNameFromPath(pkgPath string) string {
+	elements := strings.Split(pkgPath, "/")
+	name := elements[len(elements)-1]
+	if len(elements) > 1 && isMajorVersion(name) {
+		name = elements[len(elements)-2]
+	}
+	if base, version, ok := strings.Cut(name, "."); ok && isMajorVersion(version) {
+		name = base
+	}
+	return name
+}
+
+// isMajorVersion reports whether s is a major version suffix such as "v2".
+func isMajorVersion(s string) bool {
+	if len(s) < 2 || s[0] != 'v' {
+		return false
+	}
+	_, err := strconv.Atoi(s[1:])
+	return err == nil
+}
+
+// formatTypeArgs renders the type arguments of an instantiated generic type.
+//
+// reflect offers no access to the type arguments themselves: they are only recorded in
+// the type's name, as comma-separated type expressions that qualify named types with their
+// full import path, e.g. "Pair[github.com/x/y.Box[int],map[string]github.com/x/y.T]". Each
+// argument is parsed and rendered with the same qualification, recursing into the type
+// arguments of nested generic types. Since the arguments are not available as reflect.Type
+// values, Override and MaxDepth do not apply to them.
+func (tf TypeFormatter) formatTypeArgs(args string) string {
+	var rendered []string
+	depth, start := 0, 0
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case '"':
+			i = quotedStringEnd(args, i) - 1
+		case '[', '(', '{':
+			depth++
+		case ']', ')', '}':
+			depth--
+		case ',':
+			if depth == 0 {
+				rendered = append(rendered, tf.formatTypeExpr(args[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	rendered = append(rendered, tf.formatTypeExpr(args[start:]))
+	return "[" + strings.Join(rendered, ", ") + "]"
+}
+
+// formatTypeExpr renders a type expression recorded in the name of a generic type,
+// requalifying the qualified identifiers in it. Struct tags are kept only if StructTags is set.
+func (tf TypeFormatter) formatTypeExpr(expr string) string {
+	var b strings.Builder
+	for i := 0; i < len(expr); {
+		switch c := expr[i]; {
+		case c == '"':
+			end := quotedStringEnd(expr, i)
+			if tf.StructTags {
+				b.WriteString(expr[i:end])
+			} else {
+				// Drop the space separating the tag from the field type.
+				trimmed := strings.TrimSuffix(b.String(), " ")
+				b.Reset()
+				b.WriteString(trimmed)
+			}
+			i = end
+		case c == '.' && strings.HasPrefix(expr[i:], "..."):
+			b.WriteString("...")
+			i += len("...")
+		case isTypeNameByte(c):
+			end := i
+			for end < len(expr) && isTypeNameByte(expr[end]) {
+				end++
+			}
+			ident := expr[i:end]
+			i = end
+			// Predeclared types, keywords and field names are not qualified.
+			dot := strings.LastIndexByte(ident, '.')
+			if dot < 0 {
+				b.WriteString(ident)
+				continue
+			}
+			b.WriteString(tf.qualifyName(ident[:dot], "", ident[dot+1:]))
+			if i < len(expr) && expr[i] == '[' {
+				end := typeArgsEnd(expr, i)
+				b.WriteString(tf.formatTypeArgs(expr[i+1 : end-1]))
+				i = end
+			}
+		default:
+			b.WriteByte(c)
+			i++
+		}
+	}
+	return b.String()
+}
+
+// isTypeNameByte reports whether c can be part of an identifier qualified with an import
+// path. Bytes of non-ASCII characters are included, as in the "·1" suffix the compiler
+// appends to the names of types declared inside functions.
+func isTypeNameByte(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' ||
+		c == '_' || c == '.' || c == '/' || c == '-' || c == '~' || c >= 0x80
+}
+
+// quotedStringEnd returns the index following the double-quoted string starting at s[start].
+func quotedStringEnd(s string, start int) int {
+	for i := start + 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1
+		}
+	}
+	return len(s)
+}
+
+// typeArgsEnd returns the index following the bracket closing the one at s[start].
+func typeArgsEnd(s string, start int) int {
+	depth := 0
+	for i := start; i < len(s); i++ {
+		switch s[i] {
+		case '"':
+			i = quotedStringEnd(s, i) - 1
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth == 0 {
+				return i + 1
+			}
+		}
+	}
+	return len(s)
+}
@@ -98,14 +98,15 @@ func BenchmarkTypeName_generics(b *testing.B) {
 	}
 }
 
-// BenchmarkTypeName_depthLimit benchmarks TypeName with types that hit the depth limit
+// BenchmarkTypeName_depthLimit benchmarks TypeFormatter with types that hit its MaxDepth limit
 func BenchmarkTypeName_depthLimit(b *testing.B) {
-	// Create a deeply nested type that will hit the maxTypeDepth limit
+	// Create a type nested deeper than the limit of 8
 	typ := reflect.TypeOf(map[int]map[string]map[bool]map[float64]map[string]map[int]map[bool]map[string]int{})
+	formatter := commonz.TypeFormatter{MaxDepth: 8}
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		commonz.TypeName(typ)
+		formatter.Format(typ)
 	}
 }
 
@@ -130,3 +131,18 @@ func BenchmarkTypeName_vsReflect(b *testing.B) {
 		}
 	})
 }
+
+// BenchmarkTypeNameOf benchmarks the cached TypeNameOf
+func BenchmarkTypeNameOf(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		commonz.TypeNameOf[map[string]*[]int]()
+	}
+}
+
+// BenchmarkTypeNameOfValue benchmarks the cached TypeNameOfValue
+func BenchmarkTypeNameOfValue(b *testing.B) {
+	value := map[string]*[]int{}
+	for i := 0; i < b.N; i++ {
+		commonz.TypeNameOfValue(value)
+	}
+}
@@ -1,8 +1,11 @@
 package commonz_test
 
 import (
+	"fmt"
+	"io/fs"
 	"reflect"
 	"testing"
+	"time"
 
 	. "github.com/goosz/commonz"
 	"github.com/stretchr/testify/assert"
@@ -24,6 +27,21 @@ type InterfaceWithGenericArg[T any] interface {
 	Function() T
 }
 
+type (
+	NamedBool           bool
+	NamedInt            int64
+	NamedFloat          float64
+	NamedComplex        complex128
+	NamedString         string
+	NamedArray          [2]int
+	NamedSlice          []Struct
+	NamedMap            map[string]Interface
+	NamedChan           chan int
+	NamedPointer        *Struct
+	NamedFunc           func(Struct) error
+	NamedGeneric[T any] []T
+)
+
 func TestTypeName(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -165,6 +183,86 @@ func TestTypeName(t *testing.T) {
 			input:    StructWithGenericArg[Struct]{},
 			expected: "github.com/goosz/commonz_test.StructWithGenericArg[github.com/goosz/commonz_test.Struct]",
 		},
+		{
+			name:     "named bool",
+			input:    NamedBool(true),
+			expected: "github.com/goosz/commonz_test.NamedBool",
+		},
+		{
+			name:     "named int",
+			input:    NamedInt(42),
+			expected: "github.com/goosz/commonz_test.NamedInt",
+		},
+		{
+			name:     "named float",
+			input:    NamedFloat(3.14),
+			expected: "github.com/goosz/commonz_test.NamedFloat",
+		},
+		{
+			name:     "named complex",
+			input:    NamedComplex(1i),
+			expected: "github.com/goosz/commonz_test.NamedComplex",
+		},
+		{
+			name:     "named string",
+			input:    NamedString("hello"),
+			expected: "github.com/goosz/commonz_test.NamedString",
+		},
+		{
+			name:     "named array",
+			input:    NamedArray{},
+			expected: "github.com/goosz/commonz_test.NamedArray",
+		},
+		{
+			name:     "named slice",
+			input:    NamedSlice{},
+			expected: "github.com/goosz/commonz_test.NamedSlice",
+		},
+		{
+			name:     "named map",
+			input:    NamedMap{},
+			expected: "github.com/goosz/commonz_test.NamedMap",
+		},
+		{
+			name:     "named channel",
+			input:    make(NamedChan),
+			expected: "github.com/goosz/commonz_test.NamedChan",
+		},
+		{
+			name:     "named pointer",
+			input:    NamedPointer(nil),
+			expected: "github.com/goosz/commonz_test.NamedPointer",
+		},
+		{
+			name:     "named function",
+			input:    NamedFunc(nil),
+			expected: "github.com/goosz/commonz_test.NamedFunc",
+		},
+		{
+			name:     "named generic slice",
+			input:    NamedGeneric[NamedInt]{},
+			expected: "github.com/goosz/commonz_test.NamedGeneric[github.com/goosz/commonz_test.NamedInt]",
+		},
+		{
+			name:     "slice of named int",
+			input:    []NamedInt{},
+			expected: "[]github.com/goosz/commonz_test.NamedInt",
+		},
+		{
+			name:     "map of named types",
+			input:    map[NamedString]NamedSlice{},
+			expected: "map[github.com/goosz/commonz_test.NamedString]github.com/goosz/commonz_test.NamedSlice",
+		},
+		{
+			name:     "pointer to named func",
+			input:    new(NamedFunc),
+			expected: "*github.com/goosz/commonz_test.NamedFunc",
+		},
+		{
+			name:     "standard library named types",
+			input:    map[time.Duration]reflect.Kind{},
+			expected: "map[time.Duration]reflect.Kind",
+		},
 		{
 			name:     "[]error",
 			input:    []error{},
@@ -183,7 +281,450 @@ func TestTypeNameNil(t *testing.T) {
 	assert.Equal(t, "<nil>", TypeName(nil))
 }
 
+func TestTypeNameDeeplyNested(t *testing.T) {
+	typ := reflect.TypeFor[map[int][][][][][][][][]*[]chan int]()
+	assert.Equal(t, "map[int][][][][][][][][]*[]chan int", TypeName(typ), "the default depth is unlimited")
+}
+
 func TestTypeNameNestedOverflow(t *testing.T) {
 	typ := reflect.TypeOf(map[int]map[string]map[int]map[string]int{})
 	assert.Equal(t, "map[int]map[string]map[int]map[<...>]<...>", TypeNameWithDepth(typ, 4))
 }
+
+func TestTypeFormatter(t *testing.T) {
+	type anonymous = struct {
+		Field Struct
+	}
+
+	tests := []struct {
+		name      string
+		formatter TypeFormatter
+		input     any
+		expected  string
+	}{
+		{
+			name:      "zero value is TypeName",
+			formatter: TypeFormatter{},
+			input:     map[NamedString][]*Struct{},
+			expected:  "map[github.com/goosz/commonz_test.NamedString][]*github.com/goosz/commonz_test.Struct",
+		},
+		{
+			name:      "qualify with package name",
+			formatter: TypeFormatter{Qualifier: QualifyPackageName},
+			input:     map[NamedString][]*Struct{},
+			expected:  "map[commonz_test.NamedString][]*commonz_test.Struct",
+		},
+		{
+			name:      "qualify with package name for standard library",
+			formatter: TypeFormatter{Qualifier: QualifyPackageName},
+			input:     func(time.Duration) reflect.Kind { return 0 },
+			expected:  "func(time.Duration) reflect.Kind",
+		},
+		{
+			name:      "unqualified",
+			formatter: TypeFormatter{Qualifier: QualifyNone},
+			input:     map[NamedString][]*Struct{},
+			expected:  "map[NamedString][]*Struct",
+		},
+		{
+			name:      "unqualified predeclared types",
+			formatter: TypeFormatter{Qualifier: QualifyNone},
+			input:     map[string]error{},
+			expected:  "map[string]error",
+		},
+		{
+			name: "package alias",
+			formatter: TypeFormatter{PackageAliases: map[string]string{
+				"github.com/goosz/commonz_test": "ct",
+			}},
+			input:    map[time.Duration]Struct{},
+			expected: "map[time.Duration]ct.Struct",
+		},
+		{
+			name: "empty package alias",
+			formatter: TypeFormatter{Qualifier: QualifyPackageName, PackageAliases: map[string]string{
+				"github.com/goosz/commonz_test": "",
+			}},
+			input:    map[time.Duration]Struct{},
+			expected: "map[time.Duration]Struct",
+		},
+		{
+			name: "package aliases are ignored when unqualified",
+			formatter: TypeFormatter{Qualifier: QualifyNone, PackageAliases: map[string]string{
+				"github.com/goosz/commonz_test": "ct",
+			}},
+			input:    Struct{},
+			expected: "Struct",
+		},
+		{
+			name:      "max depth",
+			formatter: TypeFormatter{MaxDepth: 2},
+			input:     [][][]int{},
+			expected:  "[][]<...>",
+		},
+		{
+			name:      "anonymous struct",
+			formatter: TypeFormatter{},
+			input:     anonymous{},
+			expected:  "struct { Field github.com/goosz/commonz_test.Struct }",
+		},
+		{
+			name:      "collapsed anonymous struct",
+			formatter: TypeFormatter{CollapseAnonymous: true},
+			input:     []anonymous{},
+			expected:  "[]struct {...}",
+		},
+		{
+			name:      "collapsed empty struct",
+			formatter: TypeFormatter{CollapseAnonymous: true},
+			input:     struct{}{},
+			expected:  "struct {}",
+		},
+		{
+			name:      "collapsed anonymous interface",
+			formatter: TypeFormatter{CollapseAnonymous: true},
+			input:     []interface{ Function() }{},
+			expected:  "[]interface {...}",
+		},
+		{
+			name:      "collapsed empty interface",
+			formatter: TypeFormatter{CollapseAnonymous: true},
+			input:     []any{},
+			expected:  "[]interface {}",
+		},
+		{
+			name: "override",
+			formatter: TypeFormatter{Override: func(t reflect.Type) (string, bool) {
+				if t == reflect.TypeFor[time.Duration]() {
+					return "Duration", true
+				}
+				return "", false
+			}},
+			input:    map[string][]time.Duration{},
+			expected: "map[string][]Duration",
+		},
+		{
+			name: "override takes precedence over qualification",
+			formatter: TypeFormatter{Qualifier: QualifyNone, Override: func(t reflect.Type) (string, bool) {
+				return "T", t.Kind() == reflect.Struct
+			}},
+			input:    map[NamedString]*Struct{},
+			expected: "map[NamedString]*T",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.formatter.Format(reflect.TypeOf(tt.input)))
+		})
+	}
+}
+
+type Embedded struct{}
+
+func TestTypeNameAnonymous(t *testing.T) {
+	tests := []struct {
+		name      string
+		formatter TypeFormatter
+		input     any
+		expected  string
+	}{
+		{
+			name:     "struct with qualified field types",
+			input:    struct{ A, B Struct }{},
+			expected: "struct { A github.com/goosz/commonz_test.Struct; B github.com/goosz/commonz_test.Struct }",
+		},
+		{
+			name: "struct with embedded fields",
+			input: struct {
+				Embedded
+				*Struct
+				Name string
+			}{},
+			expected: "struct { github.com/goosz/commonz_test.Embedded; *github.com/goosz/commonz_test.Struct; Name string }",
+		},
+		{
+			name:     "struct with unexported fields",
+			input:    struct{ id NamedInt }{},
+			expected: "struct { id github.com/goosz/commonz_test.NamedInt }",
+		},
+		{
+			name: "struct tags are omitted by default",
+			input: struct {
+				ID NamedInt `json:"id"`
+			}{},
+			expected: "struct { ID github.com/goosz/commonz_test.NamedInt }",
+		},
+		{
+			name:      "struct tags",
+			formatter: TypeFormatter{StructTags: true},
+			input: struct {
+				ID   NamedInt `json:"id"`
+				Name string
+			}{},
+			expected: `struct { ID github.com/goosz/commonz_test.NamedInt "json:\"id\""; Name string }`,
+		},
+		{
+			name:     "nested anonymous structs",
+			input:    []struct{ Inner struct{ S Struct } }{},
+			expected: "[]struct { Inner struct { S github.com/goosz/commonz_test.Struct } }",
+		},
+		{
+			name: "interface with method signatures",
+			input: []interface {
+				Get(NamedString, ...int) (Struct, error)
+			}{},
+			expected: "[]interface { Get(github.com/goosz/commonz_test.NamedString, ...int) (github.com/goosz/commonz_test.Struct, error) }",
+		},
+		{
+			name: "interface with embedded interfaces",
+			input: []interface {
+				Interface
+				fmt.Stringer
+			}{},
+			expected: "[]interface { Function(); String() string }",
+		},
+		{
+			name:      "qualification applies to bodies",
+			formatter: TypeFormatter{Qualifier: QualifyPackageName},
+			input:     struct{ F func(Struct) time.Duration }{},
+			expected:  "struct { F func(commonz_test.Struct) time.Duration }",
+		},
+		{
+			name:      "depth limit applies to bodies",
+			formatter: TypeFormatter{MaxDepth: 3},
+			input:     struct{ Inner struct{ Slice [][]int } }{},
+			expected:  "struct { Inner struct { Slice []<...> } }",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.formatter.Format(reflect.TypeOf(tt.input)))
+		})
+	}
+}
+
+type Box[T any] struct {
+	Value T
+}
+
+type Pair[A, B any] struct {
+	First  A
+	Second B
+}
+
+func TestTypeNameGeneric(t *testing.T) {
+	tests := []struct {
+		name      string
+		formatter TypeFormatter
+		input     any
+		expected  string
+	}{
+		{
+			name:     "nested generics",
+			input:    Pair[Box[int], map[string]Struct]{},
+			expected: "github.com/goosz/commonz_test.Pair[github.com/goosz/commonz_test.Box[int], map[string]github.com/goosz/commonz_test.Struct]",
+		},
+		{
+			name:      "nested generics qualified with package name",
+			formatter: TypeFormatter{Qualifier: QualifyPackageName},
+			input:     Pair[Box[int], map[string]Struct]{},
+			expected:  "commonz_test.Pair[commonz_test.Box[int], map[string]commonz_test.Struct]",
+		},
+		{
+			name:      "nested generics unqualified",
+			formatter: TypeFormatter{Qualifier: QualifyNone},
+			input:     Pair[Box[int], map[string]Struct]{},
+			expected:  "Pair[Box[int], map[string]Struct]",
+		},
+		{
+			name: "nested generics with package alias",
+			formatter: TypeFormatter{PackageAliases: map[string]string{
+				"github.com/goosz/commonz_test": "ct",
+			}},
+			input:    Box[Pair[Box[Struct], *Box[time.Duration]]]{},
+			expected: "ct.Box[ct.Pair[ct.Box[ct.Struct], *ct.Box[time.Duration]]]",
+		},
+		{
+			name:      "function type argument",
+			formatter: TypeFormatter{Qualifier: QualifyNone},
+			input:     Box[func(Struct, ...NamedInt) (time.Duration, error)]{},
+			expected:  "Box[func(Struct, ...NamedInt) (Duration, error)]",
+		},
+		{
+			name:      "composite type arguments",
+			formatter: TypeFormatter{Qualifier: QualifyPackageName},
+			input:     Pair[[3]chan<- *Struct, []map[NamedString]any]{},
+			expected:  "commonz_test.Pair[[3]chan<- *commonz_test.Struct, []map[commonz_test.NamedString]interface {}]",
+		},
+		{
+			name:      "anonymous struct type argument",
+			formatter: TypeFormatter{Qualifier: QualifyNone},
+			input: Box[struct {
+				A Struct `json:"a,omitempty"`
+				B int
+			}]{},
+			expected: "Box[struct { A Struct; B int }]",
+		},
+		{
+			name:      "anonymous struct type argument with tags",
+			formatter: TypeFormatter{Qualifier: QualifyNone, StructTags: true},
+			input: Box[struct {
+				A Struct `json:"a,omitempty"`
+				B int
+			}]{},
+			expected: `Box[struct { A Struct "json:\"a,omitempty\""; B int }]`,
+		},
+		{
+			name:     "generic type nested in composite types",
+			input:    map[string][]*Box[NamedInt]{},
+			expected: "map[string][]*github.com/goosz/commonz_test.Box[github.com/goosz/commonz_test.NamedInt]",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.formatter.Format(reflect.TypeOf(tt.input)))
+		})
+	}
+}
+
+func TestTypeNameGenericLocal(t *testing.T) {
+	type Local struct{}
+
+	// The compiler numbers types declared inside functions, e.g. "Local·1".
+	name := TypeFormatter{Qualifier: QualifyNone}.Format(reflect.TypeFor[Box[Local]]())
+	assert.Regexp(t, `^Box\[Local·\d+\]$`, name)
+}
+
+type Node struct {
+	Value    int
+	Next     *Node
+	Children []Node
+}
+
+type Parent struct {
+	Children []*Child
+}
+
+type Child struct {
+	Parent *Parent
+	Name   NamedString
+}
+
+type Visitor interface {
+	Visit(*Node) Visitor
+}
+
+func TestTypeFormatterExpandNamed(t *testing.T) {
+	tests := []struct {
+		name      string
+		formatter TypeFormatter
+		input     reflect.Type
+		expected  string
+	}{
+		{
+			name:      "named struct",
+			formatter: TypeFormatter{ExpandNamed: true, Qualifier: QualifyPackageName},
+			input:     reflect.TypeFor[[]Struct](),
+			expected:  "[]commonz_test.Struct struct { Field int }",
+		},
+		{
+			name:      "other named kinds are not expanded",
+			formatter: TypeFormatter{ExpandNamed: true, Qualifier: QualifyPackageName},
+			input:     reflect.TypeFor[map[NamedString]NamedSlice](),
+			expected:  "map[commonz_test.NamedString]commonz_test.NamedSlice",
+		},
+		{
+			name:      "self-referencing struct",
+			formatter: TypeFormatter{ExpandNamed: true, Qualifier: QualifyPackageName},
+			input:     reflect.TypeFor[Node](),
+			expected:  "commonz_test.Node struct { Value int; Next *<cycle commonz_test.Node>; Children []<cycle commonz_test.Node> }",
+		},
+		{
+			name:      "mutually referencing structs",
+			formatter: TypeFormatter{ExpandNamed: true, Qualifier: QualifyNone},
+			input:     reflect.TypeFor[*Parent](),
+			expected:  "*Parent struct { Children []*Child struct { Parent *<cycle Parent>; Name NamedString } }",
+		},
+		{
+			name:      "self-referencing interface",
+			formatter: TypeFormatter{ExpandNamed: true, Qualifier: QualifyNone},
+			input:     reflect.TypeFor[Visitor](),
+			expected:  "Visitor interface { Visit(*Node struct { Value int; Next *<cycle Node>; Children []<cycle Node> }) <cycle Visitor> }",
+		},
+		{
+			name:      "repeated types that are not recursive are expanded",
+			formatter: TypeFormatter{ExpandNamed: true, Qualifier: QualifyNone},
+			input:     reflect.TypeFor[Pair[Struct, Struct]](),
+			expected:  "Pair[Struct, Struct] struct { First Struct struct { Field int }; Second Struct struct { Field int } }",
+		},
+		{
+			name:      "depth limit",
+			formatter: TypeFormatter{ExpandNamed: true, Qualifier: QualifyNone, MaxDepth: 2},
+			input:     reflect.TypeFor[Parent](),
+			expected:  "Parent struct { Children []<...> }",
+		},
+		{
+			name:      "no depth limit",
+			formatter: TypeFormatter{MaxDepth: -1, Qualifier: QualifyNone},
+			input:     reflect.TypeFor[[][][][][][][][][][]int](),
+			expected:  "[][][][][][][][][][]int",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.formatter.Format(tt.input))
+		})
+	}
+}
+
+func TestTypeFormatterNil(t *testing.T) {
+	assert.Equal(t, "<nil>", TypeFormatter{Qualifier: QualifyNone}.Format(nil))
+}
+
+func TestTypeNameOf(t *testing.T) {
+	assert.Equal(t, "int", TypeNameOf[int]())
+	assert.Equal(t, "github.com/goosz/commonz_test.Struct", TypeNameOf[Struct]())
+	assert.Equal(t, "*github.com/goosz/commonz_test.Struct", TypeNameOf[*Struct]())
+	assert.Equal(t, "error", TypeNameOf[error]())
+	assert.Equal(t, "interface {}", TypeNameOf[any]())
+	assert.Equal(t, "github.com/goosz/commonz_test.Interface", TypeNameOf[Interface]())
+	assert.Equal(t, "[]github.com/goosz/commonz_test.Interface", TypeNameOf[[]Interface]())
+	assert.Equal(t, "github.com/goosz/commonz_test.StructWithGenericArg[int]", TypeNameOf[StructWithGenericArg[int]]())
+
+	// Cached results are the same as the first ones.
+	assert.Equal(t, "github.com/goosz/commonz_test.Struct", TypeNameOf[Struct]())
+	assert.Equal(t, "error", TypeNameOf[error]())
+}
+
+func TestTypeNameOfValue(t *testing.T) {
+	var err error = &fs.PathError{}
+	var iface Interface
+
+	assert.Equal(t, "int", TypeNameOfValue(42))
+	assert.Equal(t, "github.com/goosz/commonz_test.Struct", TypeNameOfValue(Struct{}))
+	assert.Equal(t, "*io/fs.PathError", TypeNameOfValue(err), "the dynamic type of an interface value")
+	assert.Equal(t, "<nil>", TypeNameOfValue(nil))
+	assert.Equal(t, "<nil>", TypeNameOfValue(iface), "a nil interface value has no dynamic type")
+	assert.Equal(t, "*github.com/goosz/commonz_test.Struct", TypeNameOfValue((*Struct)(nil)))
+
+	// Cached results are the same as the first ones.
+	assert.Equal(t, "*io/fs.PathError", TypeNameOfValue(err))
+}
+
+func TestPackageNameFromPath(t *testing.T) {
+	tests := map[string]string{
+		"main":                     "main",
+		"net/http":                 "http",
+		"github.com/user/app":      "app",
+		"github.com/user/app/v2":   "app",
+		"gopkg.in/yaml.v3":         "yaml",
+		"example.com/lib.v1/inner": "inner",
+	}
+	for pkgPath, expected := range tests {
+		assert.Equal(t, expected, PackageNameFromPath(pkgPath), pkgPath)
+	}
+}